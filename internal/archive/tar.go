@@ -3,13 +3,107 @@ package archive
 import (
 	"archive/tar"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 )
 
+// Tar archives the contents of a source directory into a tar file.
+// If gz is true, the file is compressed with gzip.
+// Directories, regular files and symlinks are archived, with paths relative to the source directory.
+func Tar(src, tarFile string, gz bool) (err error) {
+	fw, err := os.Create(tarFile)
+	if err != nil {
+		return fmt.Errorf("creating tar file: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, fw.Close())
+	}()
+
+	var w io.Writer = fw
+	if gz {
+		gzw := gzip.NewWriter(fw)
+		defer func() {
+			err = errors.Join(err, gzw.Close())
+		}()
+		w = gzw
+	}
+
+	tw := tar.NewWriter(w)
+	defer func() {
+		err = errors.Join(err, tw.Close())
+	}()
+
+	return addTarDir(tw, src)
+}
+
+// addTarDir writes the directories, regular files and symlinks under src to a tar writer.
+func addTarDir(tw *tar.Writer, src string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+
+		isSymlink := d.Type()&fs.ModeSymlink != 0
+		if rel == "." || (!d.IsDir() && !d.Type().IsRegular() && !isSymlink) {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if isSymlink {
+			link, err = os.Readlink(p)
+			if err != nil {
+				return fmt.Errorf("reading symlink %s: %w", rel, err)
+			}
+		}
+
+		header, err := tar.FileInfoHeader(fi, filepath.ToSlash(link))
+		if err != nil {
+			return fmt.Errorf("creating tar header for %s: %w", rel, err)
+		}
+
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("writing tar header for %s: %w", rel, err)
+		}
+
+		if d.IsDir() || isSymlink {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return fmt.Errorf("opening file %s: %w", rel, err)
+		}
+		defer f.Close()
+
+		if _, err := io.Copy(tw, f); err != nil {
+			return fmt.Errorf("writing file %s: %w", rel, err)
+		}
+
+		return nil
+	})
+}
+
 // UnTar extracts a tar file to a destination directory.
 // If gz is true, the file is decompressed with gzip.
 func UnTar(tarFile, dest string, gz bool) error {
@@ -92,6 +186,22 @@ func extractTarEntry(root *os.Root, header *tar.Header, r io.Reader) error {
 		if closeErr != nil {
 			return fmt.Errorf("closing file %s: %w", name, closeErr)
 		}
+
+	case tar.TypeSymlink:
+		target := path.Join(path.Dir(name), header.Linkname)
+		if path.IsAbs(header.Linkname) || target == ".." || strings.HasPrefix(target, "../") {
+			return fmt.Errorf("symlink %s escapes the destination", name)
+		}
+
+		if dir := path.Dir(name); dir != "." {
+			if err := root.MkdirAll(dir, 0o755); err != nil {
+				return fmt.Errorf("creating parent directory for %s: %w", name, err)
+			}
+		}
+
+		if err := root.Symlink(header.Linkname, name); err != nil {
+			return fmt.Errorf("creating symlink %s: %w", name, err)
+		}
 	}
 
 	return nil
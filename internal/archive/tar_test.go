@@ -80,6 +80,61 @@ func TestUnTar(t *testing.T) {
 	}
 }
 
+func TestTar(t *testing.T) {
+	tests := []struct {
+		name string
+		gz   bool
+	}{
+		{
+			name: "can_create_tar_file",
+			gz:   false,
+		},
+		{
+			name: "can_create_tar_gz_file",
+			gz:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			src := t.TempDir()
+			is.NoErr(os.MkdirAll(path.Join(src, "dir", "empty"), 0o755))                   // should create dirs
+			is.NoErr(os.WriteFile(path.Join(src, "dir", "tool"), []byte("binary"), 0o755)) // should create file
+			is.NoErr(os.WriteFile(path.Join(src, "small.txt"), []byte("small"), 0o644))    // should create file
+			is.NoErr(os.Symlink(path.Join("dir", "tool"), path.Join(src, "link")))         // should create symlink
+			tarFile := path.Join(t.TempDir(), "archive.tar")
+
+			err := Tar(src, tarFile, tt.gz)
+
+			is.NoErr(err) // should not error
+
+			dest := t.TempDir()
+			is.NoErr(UnTar(tarFile, dest, tt.gz)) // should extract
+
+			emptyExists, _ := fileio.DirExists(path.Join(dest, "dir", "empty"))
+			is.True(emptyExists) // empty dir should be archived
+
+			data, err := os.ReadFile(path.Join(dest, "dir", "tool"))
+			is.NoErr(err)                    // should read file
+			is.Equal(string(data), "binary") // content should match
+
+			link, err := os.Readlink(path.Join(dest, "link"))
+			is.NoErr(err)                            // should archive symlink
+			is.Equal(link, path.Join("dir", "tool")) // target should match
+		})
+	}
+
+	t.Run("errors_if_the_source_does_not_exist", func(t *testing.T) {
+		is := is.New(t)
+
+		err := Tar("non-existent-dir", path.Join(t.TempDir(), "archive.tar"), false)
+
+		is.True(err != nil) // should error
+	})
+}
+
 func Test_extractTar(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -154,6 +209,7 @@ func Test_extractTarEntry(t *testing.T) {
 		wantDir  string
 		wantFile string
 		wantData string
+		wantLink string
 		wantErr  bool
 	}{
 		{
@@ -192,6 +248,22 @@ func Test_extractTarEntry(t *testing.T) {
 			name:   "skips_empty_name",
 			header: &tar.Header{Name: "/", Typeflag: tar.TypeDir, Mode: 0o755},
 		},
+		{
+			name:     "creates_relative_symlink",
+			header:   &tar.Header{Name: "bin/tool", Typeflag: tar.TypeSymlink, Linkname: "../lib/tool.js"},
+			wantFile: "bin/tool",
+			wantLink: "../lib/tool.js",
+		},
+		{
+			name:    "rejects_symlink_escaping_the_destination",
+			header:  &tar.Header{Name: "bin/tool", Typeflag: tar.TypeSymlink, Linkname: "../../outside"},
+			wantErr: true,
+		},
+		{
+			name:    "rejects_absolute_symlink",
+			header:  &tar.Header{Name: "tool", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -217,6 +289,13 @@ func Test_extractTarEntry(t *testing.T) {
 				is.True(dirExists) // directory should exist
 			}
 
+			if tt.wantLink != "" {
+				link, err := os.Readlink(path.Join(dest, tt.wantFile))
+				is.NoErr(err)               // should read symlink
+				is.Equal(link, tt.wantLink) // target should match
+				return
+			}
+
 			if tt.wantFile != "" {
 				data, err := os.ReadFile(path.Join(dest, tt.wantFile))
 				is.NoErr(err)                       // should read file
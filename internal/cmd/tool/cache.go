@@ -2,9 +2,17 @@ package tool
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/urfave/cli/v3"
+
+	"github.com/action-stars/ghactl/internal/toolkit/core"
+	"github.com/action-stars/ghactl/internal/toolkit/toolcache"
 )
 
 func (c *Cmd) cacheCommand() *cli.Command {
@@ -15,27 +23,60 @@ func (c *Cmd) cacheCommand() *cli.Command {
 			c.cacheGetCommand(),
 			c.cacheFindCommand(),
 			c.cacheAddCommand(),
+			c.cacheInfoCommand(),
+			c.cacheRemoveCommand(),
+			c.cachePruneCommand(),
+			c.cacheVerifyCommand(),
+			c.cacheExportCommand(),
+			c.cacheImportCommand(),
+			c.cacheDUCommand(),
 		},
 	}
 }
 
+// cacheGetResult is the JSON output of the cache get command.
+type cacheGetResult struct {
+	Path   string `json:"path"`
+	Source string `json:"source"`
+}
+
 func (c *Cmd) cacheGetCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "get",
 		Usage: "Get the tool cache path.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Output the path and the source it was found from as JSON.",
+			},
+		},
 		Action: func(_ context.Context, cmd *cli.Command) error {
 			slog.Debug("Getting the runner tool cache directory.")
 
-			p, err := c.CacheGet()
+			p, source, err := c.CacheGet()
 			if err != nil {
 				return exitErr(err)
 			}
 
-			if err := writeOutput(cmd, p); err != nil {
+			out := p
+			if cmd.Bool("json") {
+				data, err := json.MarshalIndent(cacheGetResult{Path: p, Source: source}, "", "  ")
+				if err != nil {
+					return exitErr(err)
+				}
+				out = string(data)
+			} else {
+				// The source goes to stderr, so stdout only has the path.
+				if _, err := fmt.Fprintf(cmd.Root().ErrWriter, "Tool cache directory from %s.\n", source); err != nil {
+					return exitErr(err)
+				}
+			}
+
+			if err := writeOutput(cmd, out); err != nil {
 				return err
 			}
 
-			slog.Debug("Runner tool cache directory retrieved.")
+			slog.Debug("Runner tool cache directory retrieved.", slog.String("source", source))
 			return nil
 		},
 	}
@@ -56,6 +97,21 @@ func (c *Cmd) cacheFindCommand() *cli.Command {
 				Name:  "version",
 				Usage: "Version spec of the tool to find.",
 			},
+			osFlag(),
+			strictFlag(),
+			&cli.BoolFlag{
+				Name:  "arch-fallback",
+				Usage: "Fall back to architectures the runner can emulate if the tool isn't cached for the requested architecture.",
+			},
+			&cli.BoolFlag{
+				Name:  "include-prerelease",
+				Usage: "Match prerelease versions against a version spec without a prerelease.",
+			},
+			&cli.StringFlag{
+				Name:  "prefer",
+				Usage: "Version to select when several versions match: stable or newest.",
+				Value: string(toolcache.PreferNewest),
+			},
 		},
 		Action: func(_ context.Context, cmd *cli.Command) error {
 			tool := cmd.String("name")
@@ -66,10 +122,24 @@ func (c *Cmd) cacheFindCommand() *cli.Command {
 				versionSpec = "*"
 			}
 
-			slog.Debug("Finding tool.", slog.String("tool", tool), slog.String("versionSpec", versionSpec), slog.Bool("all", all))
+			prefer, err := toolcache.ParseVersionPreference(cmd.String("prefer"))
+			if err != nil {
+				return exitErr(err)
+			}
+
+			options := toolcache.FindOptions{OS: cmd.String("os"), Strict: cmd.Bool("strict"), IncludePrerelease: cmd.Bool("include-prerelease"), Prefer: prefer}
+			if cmd.Bool("arch-fallback") {
+				osName := options.OS
+				if osName == "" {
+					osName = runtime.GOOS
+				}
+				options.ArchFallbacks = toolcache.DefaultArchFallbacks(osName, arch)
+			}
+
+			slog.Debug("Finding tool.", slog.String("tool", tool), slog.String("os", options.OS), slog.String("versionSpec", versionSpec), slog.Bool("all", all), slog.Bool("strict", options.Strict), slog.Any("archFallbacks", options.ArchFallbacks), slog.Bool("includePrerelease", options.IncludePrerelease), slog.String("prefer", string(prefer)))
 
 			if all {
-				vs, err := c.CacheFindAll(tool, arch)
+				vs, err := c.CacheFindAll(tool, arch, versionSpec, options)
 				if err != nil {
 					return exitErr(err)
 				}
@@ -79,35 +149,25 @@ func (c *Cmd) cacheFindCommand() *cli.Command {
 					return nil
 				}
 
-				count := 0
-
-				for _, v := range vs {
-					match, err := c.VersionCheck(v, versionSpec)
+				if len(options.ArchFallbacks) > 0 {
+					p, err := c.CacheFind(tool, arch, versionSpec, options)
 					if err != nil {
 						return exitErr(err)
 					}
+					logSelectedArch(tool, options.OS, arch, p)
+				}
 
-					if !match {
-						continue
-					}
-
+				for _, v := range vs {
 					if err := writeOutput(cmd, v); err != nil {
 						return err
 					}
-
-					count++
 				}
 
-				if count == 0 {
-					slog.Debug("Tool not found.", slog.String("tool", tool))
-					return nil
-				}
-
-				slog.Debug("Tool versions found.", slog.String("tool", tool), slog.Int("versions", count))
+				slog.Debug("Tool versions found.", slog.String("tool", tool), slog.Int("versions", len(vs)))
 				return nil
 			}
 
-			p, err := c.CacheFind(tool, arch, versionSpec)
+			p, err := c.CacheFind(tool, arch, versionSpec, options)
 			if err != nil {
 				return exitErr(err)
 			}
@@ -117,6 +177,8 @@ func (c *Cmd) cacheFindCommand() *cli.Command {
 				return nil
 			}
 
+			logSelectedArch(tool, options.OS, arch, p)
+
 			if err := writeOutput(cmd, p); err != nil {
 				return err
 			}
@@ -127,6 +189,17 @@ func (c *Cmd) cacheFindCommand() *cli.Command {
 	}
 }
 
+// logSelectedArch logs the architecture of a found tool path, warning if it is a fallback architecture.
+func logSelectedArch(tool, osName, arch, toolPath string) {
+	selectedArch := filepath.Base(toolPath)
+	if requestedArch := toolcache.PlatformArch(osName, arch); selectedArch != requestedArch {
+		slog.Warn("Tool found for a fallback architecture.", slog.String("tool", tool), slog.String("arch", requestedArch), slog.String("selectedArch", selectedArch))
+		return
+	}
+
+	slog.Debug("Tool found for the requested architecture.", slog.String("tool", tool), slog.String("arch", selectedArch))
+}
+
 func (c *Cmd) cacheAddCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "add",
@@ -151,16 +224,31 @@ func (c *Cmd) cacheAddDirCommand() *cli.Command {
 			toolNameFlag(),
 			versionFlag(),
 			archFlag(),
+			strictFlag(),
 		},
-		Action: func(_ context.Context, cmd *cli.Command) error {
+		Action: func(ctx context.Context, cmd *cli.Command) error {
 			source := cmd.String("source")
 			tool := cmd.String("name")
 			version := cmd.String("version")
 			arch := defaultArch(cmd.String("arch"))
 
+			if !cmd.Bool("strict") {
+				normalized, err := toolcache.NormalizeVersion(version)
+				if err != nil {
+					return exitErr(err)
+				}
+				version = normalized
+			}
+
 			slog.Debug("Adding tool to cache as directory.", slog.String("tool", tool), slog.String("version", version), slog.String("arch", arch))
 
-			p, err := c.CacheDir(source, tool, version, arch)
+			lock, err := toolcache.LockTool(ctx, tool, version, arch)
+			if err != nil {
+				return exitErr(err)
+			}
+			defer lock.Close()
+
+			p, err := c.CacheDir(source, tool, version, arch, toolcache.Metadata{GhactlVersion: cmd.Root().Version}, toolcache.CacheDirOptions{})
 			if err != nil {
 				return exitErr(err)
 			}
@@ -192,8 +280,9 @@ func (c *Cmd) cacheAddFileCommand() *cli.Command {
 			toolNameFlag(),
 			versionFlag(),
 			archFlag(),
+			strictFlag(),
 		},
-		Action: func(_ context.Context, cmd *cli.Command) error {
+		Action: func(ctx context.Context, cmd *cli.Command) error {
 			source := cmd.String("source")
 			targetName := cmd.String("target-name")
 			tool := cmd.String("name")
@@ -204,9 +293,23 @@ func (c *Cmd) cacheAddFileCommand() *cli.Command {
 				targetName = tool
 			}
 
+			if !cmd.Bool("strict") {
+				normalized, err := toolcache.NormalizeVersion(version)
+				if err != nil {
+					return exitErr(err)
+				}
+				version = normalized
+			}
+
 			slog.Debug("Adding tool to cache as file.", slog.String("tool", tool), slog.String("version", version), slog.String("arch", arch))
 
-			p, err := c.CacheFile(source, targetName, tool, version, arch)
+			lock, err := toolcache.LockTool(ctx, tool, version, arch)
+			if err != nil {
+				return exitErr(err)
+			}
+			defer lock.Close()
+
+			p, err := c.CacheFile(source, targetName, tool, version, arch, toolcache.Metadata{GhactlVersion: cmd.Root().Version})
 			if err != nil {
 				return exitErr(err)
 			}
@@ -220,3 +323,427 @@ func (c *Cmd) cacheAddFileCommand() *cli.Command {
 		},
 	}
 }
+
+func (c *Cmd) cacheInfoCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "info",
+		Usage: "Get the install metadata of a cached tool.",
+		Flags: []cli.Flag{
+			toolNameFlag(),
+			archFlag(),
+			&cli.StringFlag{
+				Name:  "version",
+				Usage: "Version spec of the tool.",
+			},
+			osFlag(),
+			strictFlag(),
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			tool := cmd.String("name")
+			arch := defaultArch(cmd.String("arch"))
+			versionSpec := cmd.String("version")
+			options := toolcache.FindOptions{OS: cmd.String("os"), Strict: cmd.Bool("strict")}
+
+			slog.Debug("Getting tool metadata.", slog.String("tool", tool), slog.String("versionSpec", versionSpec), slog.String("arch", arch), slog.String("os", options.OS), slog.Bool("strict", options.Strict))
+
+			metadata, err := c.CacheInfo(tool, arch, versionSpec, options)
+			if err != nil {
+				return exitErr(err)
+			}
+
+			data, err := json.MarshalIndent(metadata, "", "  ")
+			if err != nil {
+				return exitErr(err)
+			}
+
+			if err := writeOutput(cmd, string(data)); err != nil {
+				return err
+			}
+
+			slog.Debug("Tool metadata retrieved.", slog.String("tool", tool))
+			return nil
+		},
+	}
+}
+
+func (c *Cmd) cacheRemoveCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "remove",
+		Usage: "Remove tool versions from the tool cache.",
+		Flags: []cli.Flag{
+			toolNameFlag(),
+			&cli.StringFlag{
+				Name:     "version",
+				Usage:    "Version spec of the tool versions to remove.",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "arch",
+				Usage: "Architecture of the tool. Defaults to all architectures.",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			tool := cmd.String("name")
+			versionSpec := cmd.String("version")
+			arch := cmd.String("arch")
+
+			slog.Debug("Removing tool from cache.", slog.String("tool", tool), slog.String("versionSpec", versionSpec), slog.String("arch", arch))
+
+			removed, err := c.CacheRemove(ctx, tool, arch, versionSpec)
+			if err != nil {
+				return exitErr(err)
+			}
+
+			for _, p := range removed {
+				if err := writeOutput(cmd, p); err != nil {
+					return err
+				}
+			}
+
+			slog.Debug("Tool removed from cache.", slog.String("tool", tool), slog.Int("entries", len(removed)))
+			return nil
+		},
+	}
+}
+
+func (c *Cmd) cachePruneCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "prune",
+		Usage: "Remove tools from the tool cache.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "name",
+				Usage: "Name of the tool to prune. Defaults to all tools.",
+			},
+			&cli.IntFlag{
+				Name:  "keep-newest",
+				Usage: "Keep the N newest complete versions of each tool and architecture.",
+			},
+			&cli.DurationFlag{
+				Name:  "older-than",
+				Usage: "Remove entries cached longer ago than the duration.",
+			},
+			&cli.BoolFlag{
+				Name:  "incomplete",
+				Usage: "Remove entries without a completion marker.",
+			},
+			&cli.StringFlag{
+				Name:  "keep",
+				Usage: "Version spec of the entries to always keep.",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "List the entries that would be removed without removing them.",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			options := toolcache.PruneOptions{
+				Tool:            cmd.String("name"),
+				KeepNewest:      cmd.Int("keep-newest"),
+				OlderThan:       cmd.Duration("older-than"),
+				Incomplete:      cmd.Bool("incomplete"),
+				KeepVersionSpec: cmd.String("keep"),
+				DryRun:          cmd.Bool("dry-run"),
+			}
+
+			slog.Debug("Pruning tool cache.",
+				slog.String("tool", options.Tool),
+				slog.Int("keepNewest", options.KeepNewest),
+				slog.Duration("olderThan", options.OlderThan),
+				slog.Bool("incomplete", options.Incomplete),
+				slog.String("keep", options.KeepVersionSpec),
+				slog.Bool("dryRun", options.DryRun),
+			)
+
+			pruned, err := c.CachePrune(ctx, options)
+			if err != nil {
+				return exitErr(err)
+			}
+
+			var total int64
+			for _, e := range pruned {
+				if err := writeOutput(cmd, fmt.Sprintf("%s (%s)", e.Path, toolcache.FormatBytes(e.Size))); err != nil {
+					return err
+				}
+				total += e.Size
+			}
+
+			summary := fmt.Sprintf("Removed %d entries, freeing %s.", len(pruned), toolcache.FormatBytes(total))
+			if options.DryRun {
+				summary = fmt.Sprintf("Would remove %d entries, freeing %s.", len(pruned), toolcache.FormatBytes(total))
+			}
+
+			if err := writeOutput(cmd, summary); err != nil {
+				return err
+			}
+
+			slog.Debug("Tool cache pruned.", slog.Int("entries", len(pruned)), slog.Int64("bytes", total))
+			return nil
+		},
+	}
+}
+
+func (c *Cmd) cacheVerifyCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "verify",
+		Usage: "Verify the integrity of cached tools.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "name",
+				Usage: "Name of the tool to verify. Defaults to all tools.",
+			},
+			&cli.BoolFlag{
+				Name:  "fix",
+				Usage: "Invalidate tools that fail verification so they are installed again.",
+			},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			tool := cmd.String("name")
+			fix := cmd.Bool("fix")
+
+			slog.Debug("Verifying tool cache.", slog.String("tool", tool), slog.Bool("fix", fix))
+
+			results, err := c.CacheVerify(tool, fix)
+			if err != nil {
+				return exitErr(err)
+			}
+
+			failed := 0
+
+			for _, r := range results {
+				if r.Unverified {
+					slog.Warn("Tool was cached without a hash tree.", slog.String("path", r.Path))
+					continue
+				}
+
+				if r.OK() {
+					continue
+				}
+
+				lines := []string{}
+				for _, name := range r.Modified {
+					lines = append(lines, "modified "+filepath.Join(r.Path, filepath.FromSlash(name)))
+				}
+				for _, name := range r.Missing {
+					lines = append(lines, "missing "+filepath.Join(r.Path, filepath.FromSlash(name)))
+				}
+				for _, name := range r.Extra {
+					lines = append(lines, "extra "+filepath.Join(r.Path, filepath.FromSlash(name)))
+				}
+				if r.Invalidated {
+					lines = append(lines, "invalidated "+r.Path)
+				}
+
+				for _, line := range lines {
+					if err := writeOutput(cmd, line); err != nil {
+						return err
+					}
+				}
+
+				if !r.Invalidated {
+					failed++
+				}
+			}
+
+			if failed > 0 {
+				return exitErr(fmt.Errorf("%d cached tools failed verification", failed))
+			}
+
+			slog.Debug("Tool cache verified.", slog.Int("entries", len(results)))
+			return nil
+		},
+	}
+}
+
+func (c *Cmd) cacheExportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "export",
+		Usage: "Export cached tools to a bundle.",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:     "name",
+				Usage:    "Name of a tool to export. Can be repeated.",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:     "version",
+				Usage:    "Version spec of the tool versions to export. Can be repeated once per tool.",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "arch",
+				Usage: "Architecture of the tools. Defaults to all architectures.",
+			},
+			&cli.StringFlag{
+				Name:     "output",
+				Aliases:  []string{"o"},
+				Usage:    "Path to write the tar.gz bundle to.",
+				Required: true,
+			},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			tools := cmd.StringSlice("name")
+			versionSpecs := cmd.StringSlice("version")
+			arch := cmd.String("arch")
+			output := cmd.String("output")
+
+			if len(versionSpecs) != 1 && len(versionSpecs) != len(tools) {
+				return exitErr(fmt.Errorf("expected 1 or %d versions, got %d", len(tools), len(versionSpecs)))
+			}
+
+			selectors := make([]toolcache.BundleSelector, 0, len(tools))
+			for i, tool := range tools {
+				versionSpec := versionSpecs[0]
+				if len(versionSpecs) > 1 {
+					versionSpec = versionSpecs[i]
+				}
+
+				selectors = append(selectors, toolcache.BundleSelector{Tool: tool, Arch: arch, VersionSpec: versionSpec})
+			}
+
+			slog.Debug("Exporting tools from cache.", slog.Any("tools", tools), slog.Any("versionSpecs", versionSpecs), slog.String("arch", arch), slog.String("output", output))
+
+			entries, err := c.CacheExport(output, selectors)
+			if err != nil {
+				return exitErr(err)
+			}
+
+			for _, e := range entries {
+				if err := writeOutput(cmd, e.Path); err != nil {
+					return err
+				}
+			}
+
+			slog.Debug("Tools exported from cache.", slog.String("output", output), slog.Int("entries", len(entries)))
+			return nil
+		},
+	}
+}
+
+func (c *Cmd) cacheImportCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "import",
+		Usage:     "Import cached tools from a bundle.",
+		ArgsUsage: "<bundle>",
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name: "bundle",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			bundle := cmd.StringArg("bundle")
+			if bundle == "" {
+				return exitErr(fmt.Errorf("bundle is not defined"))
+			}
+
+			slog.Debug("Importing tools into cache.", slog.String("bundle", bundle))
+
+			imported, err := c.CacheImport(ctx, bundle)
+			if err != nil {
+				return exitErr(err)
+			}
+
+			for _, p := range imported {
+				if err := writeOutput(cmd, p); err != nil {
+					return err
+				}
+			}
+
+			slog.Debug("Tools imported into cache.", slog.String("bundle", bundle), slog.Int("entries", len(imported)))
+			return nil
+		},
+	}
+}
+
+// cacheDUResult is the JSON output of the cache du command.
+type cacheDUResult struct {
+	Size  int64                 `json:"size"`
+	Tools []toolcache.ToolUsage `json:"tools"`
+}
+
+func (c *Cmd) cacheDUCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "du",
+		Usage: "Report the disk usage of the tool cache.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "name",
+				Usage: "Name of the tool to report. Defaults to all tools.",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Output the disk usage as JSON.",
+			},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			tool := cmd.String("name")
+
+			slog.Debug("Getting tool cache disk usage.", slog.String("tool", tool))
+
+			usage, err := c.CacheDU(tool)
+			if err != nil {
+				return exitErr(err)
+			}
+
+			var total int64
+			for _, u := range usage {
+				total += u.Size
+			}
+
+			if cmd.Bool("json") {
+				data, err := json.MarshalIndent(cacheDUResult{Size: total, Tools: usage}, "", "  ")
+				if err != nil {
+					return exitErr(err)
+				}
+
+				if err := writeOutput(cmd, string(data)); err != nil {
+					return err
+				}
+			} else {
+				for _, u := range usage {
+					if err := writeOutput(cmd, fmt.Sprintf("%s (%s)", u.Tool, toolcache.FormatBytes(u.Size))); err != nil {
+						return err
+					}
+
+					for _, e := range u.Entries {
+						if err := writeOutput(cmd, fmt.Sprintf("  %s/%s (%s)", e.Version, e.Arch, toolcache.FormatBytes(e.Size))); err != nil {
+							return err
+						}
+					}
+				}
+
+				if err := writeOutput(cmd, fmt.Sprintf("Total %s.", toolcache.FormatBytes(total))); err != nil {
+					return err
+				}
+			}
+
+			if core.IsGitHubActions() {
+				if err := core.WriteSummary(formatDUSummary(usage, total)); err != nil {
+					return exitErr(err)
+				}
+			}
+
+			slog.Debug("Tool cache disk usage retrieved.", slog.Int("tools", len(usage)), slog.Int64("bytes", total))
+			return nil
+		},
+	}
+}
+
+// formatDUSummary formats the tool cache disk usage as a Markdown table for the step summary.
+func formatDUSummary(usage []toolcache.ToolUsage, total int64) string {
+	var sb strings.Builder
+
+	sb.WriteString("### Tool cache disk usage\n\n")
+	sb.WriteString("| Tool | Version | Arch | Size |\n")
+	sb.WriteString("| ---- | ------- | ---- | ---: |\n")
+
+	for _, u := range usage {
+		for _, e := range u.Entries {
+			fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n", u.Tool, e.Version, e.Arch, toolcache.FormatBytes(e.Size))
+		}
+	}
+
+	fmt.Fprintf(&sb, "| **Total** | | | **%s** |\n", toolcache.FormatBytes(total))
+
+	return sb.String()
+}
@@ -1,19 +1,23 @@
 package tool
 
 import (
+	"context"
+	"path/filepath"
 	"testing"
 
 	"github.com/matryer/is"
+
+	"github.com/action-stars/ghactl/internal/toolkit/toolcache"
 )
 
 func TestCmd_CacheGet(t *testing.T) {
 	c := &Cmd{}
 
-	t.Run("errors_if_tool_cache_dir_env_variable_is_not_defined", func(t *testing.T) {
+	t.Run("errors_if_tool_cache_dir_does_not_exist", func(t *testing.T) {
 		is := is.New(t)
-		t.Setenv("RUNNER_TOOL_CACHE", "")
+		t.Setenv("RUNNER_TOOL_CACHE", filepath.Join(t.TempDir(), "non-existent"))
 
-		_, err := c.CacheGet()
+		_, _, err := c.CacheGet()
 
 		is.True(err != nil) // should error
 	})
@@ -22,21 +26,35 @@ func TestCmd_CacheGet(t *testing.T) {
 		is := is.New(t)
 		tc := setupToolCache(t)
 
-		result, err := c.CacheGet()
+		result, source, err := c.CacheGet()
 
-		is.NoErr(err)        // should not error
-		is.Equal(result, tc) // should return expected path
+		is.NoErr(err)                         // should not error
+		is.Equal(result, tc)                  // should return expected path
+		is.Equal(source, "RUNNER_TOOL_CACHE") // should return the source
+	})
+
+	t.Run("returns_the_configured_tool_cache_dir", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", "")
+		t.Setenv("GHACTL_TOOL_CACHE", tc)
+
+		result, source, err := c.CacheGet()
+
+		is.NoErr(err)                         // should not error
+		is.Equal(result, tc)                  // should return expected path
+		is.Equal(source, "GHACTL_TOOL_CACHE") // should return the source
 	})
 }
 
 func TestCmd_CacheFindAll(t *testing.T) {
 	c := &Cmd{}
 
-	t.Run("errors_if_tool_cache_dir_is_not_defined", func(t *testing.T) {
+	t.Run("errors_if_tool_cache_dir_does_not_exist", func(t *testing.T) {
 		is := is.New(t)
-		t.Setenv("RUNNER_TOOL_CACHE", "")
+		t.Setenv("RUNNER_TOOL_CACHE", filepath.Join(t.TempDir(), "non-existent"))
 
-		_, err := c.CacheFindAll("test-tool", "amd64")
+		_, err := c.CacheFindAll("test-tool", "amd64", "", toolcache.FindOptions{})
 
 		is.True(err != nil) // should error
 	})
@@ -45,7 +63,7 @@ func TestCmd_CacheFindAll(t *testing.T) {
 		is := is.New(t)
 		setupToolCache(t)
 
-		ps, err := c.CacheFindAll("non-existent", "amd64")
+		ps, err := c.CacheFindAll("non-existent", "amd64", "", toolcache.FindOptions{})
 
 		is.NoErr(err)        // should not error
 		is.Equal(len(ps), 0) // should return empty
@@ -55,7 +73,7 @@ func TestCmd_CacheFindAll(t *testing.T) {
 		is := is.New(t)
 		setupToolCache(t)
 
-		ps, err := c.CacheFindAll("test-tool", "amd64")
+		ps, err := c.CacheFindAll("test-tool", "amd64", "", toolcache.FindOptions{})
 
 		is.NoErr(err)        // should not error
 		is.True(len(ps) > 0) // should find versions
@@ -65,11 +83,11 @@ func TestCmd_CacheFindAll(t *testing.T) {
 func TestCmd_CacheFind(t *testing.T) {
 	c := &Cmd{}
 
-	t.Run("errors_if_tool_cache_dir_is_not_defined", func(t *testing.T) {
+	t.Run("errors_if_tool_cache_dir_does_not_exist", func(t *testing.T) {
 		is := is.New(t)
-		t.Setenv("RUNNER_TOOL_CACHE", "")
+		t.Setenv("RUNNER_TOOL_CACHE", filepath.Join(t.TempDir(), "non-existent"))
 
-		_, err := c.CacheFind("test-tool", "amd64", "*")
+		_, err := c.CacheFind("test-tool", "amd64", "*", toolcache.FindOptions{})
 
 		is.True(err != nil) // should error
 	})
@@ -78,7 +96,7 @@ func TestCmd_CacheFind(t *testing.T) {
 		is := is.New(t)
 		setupToolCache(t)
 
-		p, err := c.CacheFind("non-existent", "amd64", "*")
+		p, err := c.CacheFind("non-existent", "amd64", "*", toolcache.FindOptions{})
 
 		is.NoErr(err)   // should not error
 		is.Equal(p, "") // should return empty
@@ -88,7 +106,7 @@ func TestCmd_CacheFind(t *testing.T) {
 		is := is.New(t)
 		setupToolCache(t)
 
-		p, err := c.CacheFind("test-tool", "amd64", "^1.0.0")
+		p, err := c.CacheFind("test-tool", "amd64", "^1.0.0", toolcache.FindOptions{})
 
 		is.NoErr(err)    // should not error
 		is.True(p != "") // should find tool
@@ -98,7 +116,7 @@ func TestCmd_CacheFind(t *testing.T) {
 		is := is.New(t)
 		setupToolCache(t)
 
-		p, err := c.CacheFind("test-tool", "", "*")
+		p, err := c.CacheFind("test-tool", "", "*", toolcache.FindOptions{})
 
 		is.NoErr(err) // should not error
 		// result depends on runtime.GOARCH; just ensure no error
@@ -109,11 +127,23 @@ func TestCmd_CacheFind(t *testing.T) {
 		is := is.New(t)
 		setupToolCache(t)
 
-		p, err := c.CacheFind("test-tool", "amd64", "")
+		p, err := c.CacheFind("test-tool", "amd64", "", toolcache.FindOptions{})
 
 		is.NoErr(err)    // should not error
 		is.True(p != "") // should find latest
 	})
+
+	t.Run("finds_tool_for_fallback_arch", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		createCacheEntry(t, tc, "test-tool", "1.0.0", "x64")
+
+		p, err := c.CacheFind("test-tool", "arm64", "*", toolcache.FindOptions{ArchFallbacks: []string{"x64"}})
+
+		is.NoErr(err)                                               // should not error
+		is.Equal(p, filepath.Join(tc, "test-tool", "1.0.0", "x64")) // should select the fallback arch
+	})
 }
 
 func TestCmd_CacheDir(t *testing.T) {
@@ -124,7 +154,7 @@ func TestCmd_CacheDir(t *testing.T) {
 		tc := t.TempDir()
 		t.Setenv("RUNNER_TOOL_CACHE", tc)
 
-		_, err := c.CacheDir("/nonexistent", "my-tool", "1.0.0", "amd64")
+		_, err := c.CacheDir("/nonexistent", "my-tool", "1.0.0", "amd64", toolcache.Metadata{}, toolcache.CacheDirOptions{})
 
 		is.True(err != nil) // should error
 	})
@@ -135,7 +165,7 @@ func TestCmd_CacheDir(t *testing.T) {
 		t.Setenv("RUNNER_TOOL_CACHE", tc)
 		source := createSourceDir(t)
 
-		p, err := c.CacheDir(source, "my-tool", "1.0.0", "amd64")
+		p, err := c.CacheDir(source, "my-tool", "1.0.0", "amd64", toolcache.Metadata{}, toolcache.CacheDirOptions{})
 
 		is.NoErr(err)    // should not error
 		is.True(p != "") // should return path
@@ -150,7 +180,7 @@ func TestCmd_CacheFile(t *testing.T) {
 		tc := t.TempDir()
 		t.Setenv("RUNNER_TOOL_CACHE", tc)
 
-		_, err := c.CacheFile("/nonexistent", "my-tool", "my-tool", "1.0.0", "amd64")
+		_, err := c.CacheFile("/nonexistent", "my-tool", "my-tool", "1.0.0", "amd64", toolcache.Metadata{})
 
 		is.True(err != nil) // should error
 	})
@@ -161,7 +191,7 @@ func TestCmd_CacheFile(t *testing.T) {
 		t.Setenv("RUNNER_TOOL_CACHE", tc)
 		source := createSourceFile(t)
 
-		p, err := c.CacheFile(source, "my-tool", "my-tool", "1.0.0", "amd64")
+		p, err := c.CacheFile(source, "my-tool", "my-tool", "1.0.0", "amd64", toolcache.Metadata{})
 
 		is.NoErr(err)    // should not error
 		is.True(p != "") // should return path
@@ -173,9 +203,207 @@ func TestCmd_CacheFile(t *testing.T) {
 		t.Setenv("RUNNER_TOOL_CACHE", tc)
 		source := createSourceFile(t)
 
-		p, err := c.CacheFile(source, "", "my-tool", "1.0.0", "amd64")
+		p, err := c.CacheFile(source, "", "my-tool", "1.0.0", "amd64", toolcache.Metadata{})
 
 		is.NoErr(err)    // should not error
 		is.True(p != "") // should return path
 	})
 }
+
+func TestCmd_CacheInfo(t *testing.T) {
+	c := &Cmd{}
+
+	t.Run("errors_if_tool_is_not_cached", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+
+		_, err := c.CacheInfo("my-tool", "amd64", "1.0.0", toolcache.FindOptions{})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("returns_metadata_for_cached_tool", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		source := createSourceFile(t)
+
+		_, err := c.CacheFile(source, "my-tool", "my-tool", "1.0.0", "amd64", toolcache.Metadata{})
+		is.NoErr(err) // should not error
+
+		metadata, err := c.CacheInfo("my-tool", "amd64", "", toolcache.FindOptions{})
+
+		is.NoErr(err)                          // should not error
+		is.Equal(metadata.Version, "1.0.0")    // should match version
+		is.Equal(metadata.Source.Path, source) // should record source
+	})
+}
+
+func TestCmd_CacheRemove(t *testing.T) {
+	c := &Cmd{}
+
+	t.Run("errors_if_version_spec_is_invalid", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+
+		_, err := c.CacheRemove(t.Context(), "my-tool", "", "bad")
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("removes_matching_versions", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		p := createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+		createCacheEntry(t, tc, "my-tool", "2.0.0", "x64")
+
+		removed, err := c.CacheRemove(t.Context(), "my-tool", "amd64", "^1.0.0")
+
+		is.NoErr(err)                  // should not error
+		is.Equal(removed, []string{p}) // should remove matching version
+	})
+}
+
+func TestCmd_CachePrune(t *testing.T) {
+	c := &Cmd{}
+
+	t.Run("errors_if_no_policy_is_defined", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+
+		_, err := c.CachePrune(t.Context(), toolcache.PruneOptions{})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("prunes_old_versions", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		old := createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+		createCacheEntry(t, tc, "my-tool", "1.1.0", "x64")
+
+		pruned, err := c.CachePrune(t.Context(), toolcache.PruneOptions{KeepNewest: 1})
+
+		is.NoErr(err)                 // should not error
+		is.Equal(len(pruned), 1)      // should prune one entry
+		is.Equal(pruned[0].Path, old) // should prune the oldest version
+	})
+}
+
+func TestCmd_CacheVerify(t *testing.T) {
+	c := &Cmd{}
+
+	t.Run("errors_if_tool_cache_dir_does_not_exist", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", filepath.Join(t.TempDir(), "non-existent"))
+
+		_, err := c.CacheVerify("", false)
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("verifies_cached_tools", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		source := createSourceDir(t)
+
+		_, err := c.CacheDir(source, "my-tool", "1.0.0", "amd64", toolcache.Metadata{}, toolcache.CacheDirOptions{})
+		is.NoErr(err) // should not error
+
+		results, err := c.CacheVerify("my-tool", false)
+
+		is.NoErr(err)             // should not error
+		is.Equal(len(results), 1) // should verify the tool
+		is.True(results[0].OK())  // should pass verification
+	})
+}
+
+func TestCmd_CacheExport(t *testing.T) {
+	c := &Cmd{}
+
+	t.Run("errors_if_tool_is_not_cached", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		setupTempDir(t)
+
+		_, err := c.CacheExport(filepath.Join(t.TempDir(), "bundle.tar.gz"), []toolcache.BundleSelector{{Tool: "my-tool", VersionSpec: "*"}})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("exports_matching_versions", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		setupTempDir(t)
+		p := createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+		createCacheEntry(t, tc, "my-tool", "2.0.0", "x64")
+
+		entries, err := c.CacheExport(filepath.Join(t.TempDir(), "bundle.tar.gz"), []toolcache.BundleSelector{{Tool: "my-tool", VersionSpec: "^1.0.0"}})
+
+		is.NoErr(err)                // should not error
+		is.Equal(len(entries), 1)    // should export matching version
+		is.Equal(entries[0].Path, p) // should export the cached entry
+	})
+}
+
+func TestCmd_CacheImport(t *testing.T) {
+	c := &Cmd{}
+
+	t.Run("errors_if_bundle_does_not_exist", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		setupTempDir(t)
+
+		_, err := c.CacheImport(context.Background(), "non-existent.tar.gz")
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("imports_exported_versions", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		setupTempDir(t)
+		p := createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+		bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+		_, err := c.CacheExport(bundle, []toolcache.BundleSelector{{Tool: "my-tool", VersionSpec: "*"}})
+		is.NoErr(err) // should export
+		_, err = c.CacheRemove(t.Context(), "my-tool", "", "*")
+		is.NoErr(err) // should remove
+
+		imported, err := c.CacheImport(context.Background(), bundle)
+
+		is.NoErr(err)                   // should not error
+		is.Equal(imported, []string{p}) // should import the exported version
+	})
+}
+
+func TestCmd_CacheDU(t *testing.T) {
+	c := &Cmd{}
+
+	t.Run("errors_if_tool_cache_dir_does_not_exist", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", filepath.Join(t.TempDir(), "non-existent"))
+
+		_, err := c.CacheDU("")
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("returns_disk_usage", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+		createCacheEntry(t, tc, "other-tool", "2.0.0", "x64")
+
+		usage, err := c.CacheDU("my-tool")
+
+		is.NoErr(err)                      // should not error
+		is.Equal(len(usage), 1)            // should only report the tool
+		is.Equal(usage[0].Tool, "my-tool") // should report the tool
+		is.Equal(usage[0].Size, int64(6))  // should report the tool size
+	})
+}
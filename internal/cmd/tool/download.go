@@ -2,38 +2,654 @@ package tool
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/urfave/cli/v3"
+
+	"github.com/action-stars/ghactl/internal/toolkit/core"
+	"github.com/action-stars/ghactl/internal/toolkit/httpclient"
+	"github.com/action-stars/ghactl/internal/toolkit/toolcache"
 )
 
+// defaultDownloadCacheMaxSize is the default maximum size of the download cache.
+const defaultDownloadCacheMaxSize = "5GiB"
+
+// defaultDownloadParallel is the default maximum number of concurrent downloads from a manifest.
+const defaultDownloadParallel = 4
+
+// downloadManifestResult is the JSON output of a download manifest entry.
+type downloadManifestResult struct {
+	downloadResult
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// downloadResult is the JSON output of the download command.
+type downloadResult struct {
+	Path      string `json:"path"`
+	URL       string `json:"url"`
+	MimeType  string `json:"mimeType"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+	SHA512    string `json:"sha512"`
+	Cached    bool   `json:"cached"`
+	Signature string `json:"signature"`
+}
+
 func (c *Cmd) downloadCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "download",
-		Usage: "Download a tool to a temporary directory.",
-		Flags: []cli.Flag{
+		Usage: "Download a tool to a temporary directory or a destination.",
+		Flags: slices.Concat([]cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "url",
+				Usage: "URL to download the tool from, can be repeated to try fallback URLs in order.",
+			},
+			&cli.StringFlag{
+				Name:  "sha256",
+				Usage: "Expected SHA-256 digest of the tool.",
+			},
+			&cli.StringFlag{
+				Name:  "sha512",
+				Usage: "Expected SHA-512 digest of the tool.",
+			},
+			&cli.StringFlag{
+				Name:  "checksum-url",
+				Usage: "URL of a checksum file with the expected digest of the tool.",
+			},
+			&cli.StringFlag{
+				Name:  "checksum-file",
+				Usage: "Path to a checksum file with the expected digest of the tool.",
+			},
+			&cli.StringSliceFlag{
+				Name:  "header",
+				Usage: "Request header in 'Name: value' format, can be repeated.",
+			},
+			&cli.StringFlag{
+				Name:  "token",
+				Usage: "Bearer token to send in the Authorization header.",
+			},
+			&cli.BoolFlag{
+				Name:  "netrc",
+				Usage: "Send credentials for the host from the netrc file.",
+			},
+			&cli.StringFlag{
+				Name:  "dest",
+				Usage: "Directory or file path to save the tool to, instead of a temporary directory.",
+			},
+			&cli.BoolFlag{
+				Name:  "overwrite",
+				Usage: "Overwrite an existing file at the destination.",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Output the path, URL, MIME type, size, digests, cache status and signature format of the tool as JSON.",
+			},
+			quietFlag(),
 			&cli.StringFlag{
-				Name:     "url",
-				Usage:    "URL to download the tool from.",
-				Required: true,
+				Name:  "signature-url",
+				Usage: "URL of a detached signature of the tool. Defaults to the first of <url>.minisig, <url>.sig and <url>.asc found.",
 			},
+			&cli.StringFlag{
+				Name:  "signature-file",
+				Usage: "Path to a detached signature of the tool.",
+			},
+			&cli.StringFlag{
+				Name:  "manifest",
+				Usage: "Path to a JSON manifest of url, sha256 and dest entries to download, instead of --url.",
+			},
+			&cli.IntFlag{
+				Name:    "parallel",
+				Usage:   "Maximum number of concurrent downloads from the manifest.",
+				Value:   defaultDownloadParallel,
+				Sources: cli.EnvVars("GHACTL_DOWNLOAD_PARALLEL"),
+			},
+			&cli.BoolFlag{
+				Name:  "fail-fast",
+				Usage: "Cancel the remaining downloads from the manifest after the first failure, instead of reporting every failure.",
+			},
+		}, signatureFlags(), downloadOptionFlags(), tlsFlags()),
+		Commands: []*cli.Command{
+			c.downloadCacheCommand(),
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
-			rawURL := cmd.String("url")
+			rawURLs := cmd.StringSlice("url")
+			manifest := cmd.String("manifest")
+
+			// The URL isn't a required flag, so the cache subcommand can run without it.
+			if len(rawURLs) == 0 && manifest == "" {
+				return exitErr(fmt.Errorf("url is not defined"))
+			}
+
+			if manifest != "" {
+				for _, name := range []string{"url", "sha256", "sha512", "checksum-url", "checksum-file", "public-key", "signature-url", "signature-file"} {
+					if cmd.IsSet(name) {
+						return exitErr(fmt.Errorf("%s can't be used with manifest", name))
+					}
+				}
+			}
+
+			if err := configureTLS(cmd); err != nil {
+				return exitErr(err)
+			}
+
+			headers, err := parseHeaders(cmd.StringSlice("header"))
+			if err != nil {
+				return exitErr(err)
+			}
+
+			options, err := getDownloadOptions(cmd)
+			if err != nil {
+				return exitErr(err)
+			}
+
+			options.Headers = headers
+			options.Token = cmd.String("token")
+			options.Netrc = cmd.Bool("netrc")
+			options.Dest = cmd.String("dest")
+			options.Overwrite = cmd.Bool("overwrite")
+
+			if core.IsGitHubActions() {
+				if err := maskCredentials(cmd.Root().ErrWriter, options); err != nil {
+					return exitErr(err)
+				}
+			}
 
-			slog.Debug("Downloading tool.", slog.String("url", rawURL))
+			if manifest != "" {
+				return c.downloadManifestAction(ctx, cmd, manifest, options)
+			}
+
+			// The first URL names the download, for finding its checksum and in the logs.
+			rawURL := rawURLs[0]
+
+			headerNames := []string{}
+			for k := range headers {
+				headerNames = append(headerNames, k)
+			}
+			slices.Sort(headerNames)
+
+			slog.Debug("Downloading tool.", slog.Any("urls", rawURLs), slog.Any("headers", headerNames), slog.Bool("token", options.Token != ""), slog.Bool("netrc", options.Netrc))
+
+			checksums, err := c.DownloadChecksums(ctx, rawURL, DownloadChecksumOptions{
+				SHA256:       cmd.String("sha256"),
+				SHA512:       cmd.String("sha512"),
+				ChecksumURL:  cmd.String("checksum-url"),
+				ChecksumFile: cmd.String("checksum-file"),
+				Request:      options,
+			})
+			if err != nil {
+				return exitErr(err)
+			}
+
+			options.Checksums = checksums
+
+			signature, err := c.DownloadSignature(ctx, rawURL, DownloadSignatureOptions{
+				PublicKey:     cmd.String("public-key"),
+				SignatureURL:  cmd.String("signature-url"),
+				SignatureFile: cmd.String("signature-file"),
+				Namespace:     cmd.String("signature-namespace"),
+				Request:       options,
+			})
+			if err != nil {
+				return exitErr(err)
+			}
+
+			options.Signature = signature
+
+			// Progress goes to stderr, so stdout only has the output.
+			grouped := false
+			if !cmd.Bool("quiet") {
+				options.Progress = cmd.Root().ErrWriter
+
+				if core.IsGitHubActions() {
+					if err := core.StartGroup(options.Progress, getDownloadGroupName(rawURL)); err != nil {
+						return exitErr(err)
+					}
+					grouped = true
+				}
+			}
 
-			p, err := c.Download(ctx, rawURL)
+			result, err := c.Download(ctx, rawURLs, options)
+			if grouped {
+				err = errors.Join(err, core.EndGroup(options.Progress))
+			}
 			if err != nil {
 				return exitErr(err)
 			}
 
-			if err := writeOutput(cmd, p); err != nil {
+			if core.IsGitHubActions() {
+				if err := core.SetOutput("sha256", result.SHA256); err != nil {
+					return exitErr(err)
+				}
+
+				if err := core.SetOutput("sha512", result.SHA512); err != nil {
+					return exitErr(err)
+				}
+			}
+
+			out := result.Path
+			if cmd.Bool("json") {
+				data, err := json.MarshalIndent(downloadResult{
+					Path:      result.Path,
+					URL:       result.URL,
+					MimeType:  result.ContentType,
+					Size:      result.Size,
+					SHA256:    result.SHA256,
+					SHA512:    result.SHA512,
+					Cached:    result.Cached,
+					Signature: string(result.Signature),
+				}, "", "  ")
+				if err != nil {
+					return exitErr(err)
+				}
+				out = string(data)
+			}
+
+			if err := writeOutput(cmd, out); err != nil {
 				return err
 			}
 
-			slog.Debug("Tool downloaded successfully.")
+			slog.Debug("Tool downloaded successfully.", slog.String("url", result.URL), slog.Bool("cached", result.Cached), slog.String("sha256", result.SHA256), slog.Int("verifiedChecksums", len(checksums)), slog.String("signature", string(result.Signature)))
 			return nil
 		},
 	}
 }
+
+func (c *Cmd) downloadCacheCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "cache",
+		Usage: "Manage the download cache.",
+		Commands: []*cli.Command{
+			{
+				Name:  "clear",
+				Usage: "Remove all the downloads from the download cache.",
+				Action: func(_ context.Context, cmd *cli.Command) error {
+					slog.Debug("Clearing the download cache.")
+
+					count, size, err := c.DownloadCacheClear()
+					if err != nil {
+						return exitErr(err)
+					}
+
+					if err := writeOutput(cmd, fmt.Sprintf("Removed %d cached downloads, freeing %s.", count, toolcache.FormatBytes(size))); err != nil {
+						return err
+					}
+
+					slog.Debug("Download cache cleared.", slog.Int("downloads", count), slog.Int64("bytes", size))
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// downloadManifestAction downloads the entries of a download manifest and outputs a table of the results.
+func (c *Cmd) downloadManifestAction(ctx context.Context, cmd *cli.Command, manifest string, options toolcache.DownloadOptions) error {
+	entries, err := toolcache.ReadDownloadManifest(manifest)
+	if err != nil {
+		return exitErr(err)
+	}
+
+	parallel := cmd.Int("parallel")
+	if parallel < 1 {
+		return exitErr(fmt.Errorf("parallel must be at least 1"))
+	}
+
+	// The --dest flag is the directory for entries without a destination, as several files can't share a path.
+	if options.Dest != "" && !strings.HasSuffix(options.Dest, string(filepath.Separator)) && !strings.HasSuffix(options.Dest, "/") {
+		options.Dest += string(filepath.Separator)
+	}
+
+	slog.Debug("Downloading tools from manifest.", slog.String("manifest", manifest), slog.Int("entries", len(entries)), slog.Int("parallel", parallel), slog.Bool("failFast", cmd.Bool("fail-fast")))
+
+	results := c.DownloadManifest(ctx, entries, DownloadManifestOptions{
+		Parallel: parallel,
+		FailFast: cmd.Bool("fail-fast"),
+		Download: options,
+	})
+
+	errs := []error{}
+	for _, r := range results {
+		if r.Err != nil {
+			slog.Warn("Download failed.", slog.String("url", r.Entry.URL), slog.Any("error", r.Err))
+			errs = append(errs, fmt.Errorf("downloading %s: %w", r.Entry.URL, r.Err))
+		}
+	}
+
+	var out string
+	if cmd.Bool("json") {
+		data, err := json.MarshalIndent(getDownloadManifestResults(results), "", "  ")
+		if err != nil {
+			return exitErr(err)
+		}
+		out = string(data)
+	} else {
+		out, err = formatDownloadManifestResults(results)
+		if err != nil {
+			return exitErr(err)
+		}
+	}
+
+	if err := writeOutput(cmd, out); err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return exitErr(errors.Join(errs...))
+	}
+
+	slog.Debug("Tools downloaded from manifest successfully.", slog.Int("entries", len(entries)))
+	return nil
+}
+
+// getDownloadManifestResults returns the JSON output of the results of a download manifest.
+func getDownloadManifestResults(results []DownloadManifestResult) []downloadManifestResult {
+	out := []downloadManifestResult{}
+
+	for _, r := range results {
+		item := downloadManifestResult{
+			downloadResult: downloadResult{URL: r.Entry.URL},
+			Status:         getDownloadManifestStatus(r),
+		}
+
+		if r.Err != nil {
+			item.Error = r.Err.Error()
+		}
+
+		if r.Result != nil {
+			item.downloadResult = downloadResult{
+				Path:      r.Result.Path,
+				URL:       r.Result.URL,
+				MimeType:  r.Result.ContentType,
+				Size:      r.Result.Size,
+				SHA256:    r.Result.SHA256,
+				SHA512:    r.Result.SHA512,
+				Cached:    r.Result.Cached,
+				Signature: string(r.Result.Signature),
+			}
+		}
+
+		out = append(out, item)
+	}
+
+	return out
+}
+
+// formatDownloadManifestResults returns a table of the status, size, path and URL of each download manifest entry.
+func formatDownloadManifestResults(results []DownloadManifestResult) (string, error) {
+	var buf strings.Builder
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tSIZE\tPATH\tURL")
+
+	for _, r := range results {
+		size, p := "-", "-"
+		if r.Result != nil {
+			size, p = toolcache.FormatBytes(r.Result.Size), r.Result.Path
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", getDownloadManifestStatus(r), size, p, r.Entry.URL)
+	}
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// getDownloadManifestStatus returns the status of a download manifest entry.
+func getDownloadManifestStatus(r DownloadManifestResult) string {
+	switch {
+	case r.Skipped:
+		return "skipped"
+	case r.Err != nil:
+		return "failed"
+	case r.Result != nil && r.Result.Cached:
+		return "cached"
+	default:
+		return "downloaded"
+	}
+}
+
+// parseHeaders parses request headers in "Name: value" format.
+func parseHeaders(values []string) (http.Header, error) {
+	headers := http.Header{}
+
+	for _, v := range values {
+		name, value, ok := strings.Cut(v, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("header %q is not in 'Name: value' format", v)
+		}
+
+		headers.Add(name, strings.TrimSpace(value))
+	}
+
+	return headers, nil
+}
+
+// downloadOptionFlags returns the flags for the retry policy, timeout, maximum size and caching of a download.
+// Their defaults can be set with environment variables, so they apply to every download in a workflow.
+func downloadOptionFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:    "retries",
+			Usage:   "Maximum number of times a failed request is retried.",
+			Value:   toolcache.DefaultRetries,
+			Sources: cli.EnvVars("GHACTL_DOWNLOAD_RETRIES"),
+		},
+		&cli.DurationFlag{
+			Name:    "retry-wait-min",
+			Usage:   "Minimum wait before retrying a request.",
+			Value:   toolcache.DefaultRetryWaitMin,
+			Sources: cli.EnvVars("GHACTL_DOWNLOAD_RETRY_WAIT_MIN"),
+		},
+		&cli.DurationFlag{
+			Name:    "retry-wait-max",
+			Usage:   "Maximum wait before retrying a request.",
+			Value:   toolcache.DefaultRetryWaitMax,
+			Sources: cli.EnvVars("GHACTL_DOWNLOAD_RETRY_WAIT_MAX"),
+		},
+		&cli.IntSliceFlag{
+			Name:    "retry-status",
+			Usage:   "Response status that is retried, can be repeated. Defaults to 429 and 5xx statuses other than 501.",
+			Sources: cli.EnvVars("GHACTL_DOWNLOAD_RETRY_STATUS"),
+		},
+		&cli.DurationFlag{
+			Name:    "timeout",
+			Usage:   "Overall timeout of the download, including retries. Defaults to no timeout.",
+			Sources: cli.EnvVars("GHACTL_DOWNLOAD_TIMEOUT"),
+		},
+		&cli.StringFlag{
+			Name:    "max-size",
+			Usage:   "Maximum size of the download, such as 500MiB. Defaults to no limit.",
+			Sources: cli.EnvVars("GHACTL_DOWNLOAD_MAX_SIZE"),
+		},
+		&cli.BoolFlag{
+			Name:    "cache",
+			Usage:   "Restore the download from, and add it to, the download cache in the tool cache.",
+			Sources: cli.EnvVars("GHACTL_DOWNLOAD_CACHE"),
+		},
+		&cli.StringFlag{
+			Name:    "cache-max-size",
+			Usage:   "Maximum size of the download cache, evicting the least recently used downloads.",
+			Value:   defaultDownloadCacheMaxSize,
+			Sources: cli.EnvVars("GHACTL_DOWNLOAD_CACHE_MAX_SIZE"),
+		},
+	}
+}
+
+// getDownloadOptions returns download options with the retry policy, timeout, maximum size and caching from the flags.
+func getDownloadOptions(cmd *cli.Command) (toolcache.DownloadOptions, error) {
+	retry := toolcache.RetryPolicy{
+		Max:         cmd.Int("retries"),
+		WaitMin:     cmd.Duration("retry-wait-min"),
+		WaitMax:     cmd.Duration("retry-wait-max"),
+		StatusCodes: cmd.IntSlice("retry-status"),
+	}
+
+	if retry.Max < 0 {
+		return toolcache.DownloadOptions{}, fmt.Errorf("retries must not be negative")
+	}
+
+	if retry.WaitMin > retry.WaitMax {
+		return toolcache.DownloadOptions{}, fmt.Errorf("retry wait min %s is longer than retry wait max %s", retry.WaitMin, retry.WaitMax)
+	}
+
+	for _, code := range retry.StatusCodes {
+		if code < 100 || code > 599 {
+			return toolcache.DownloadOptions{}, fmt.Errorf("retry status %d is not a valid HTTP status", code)
+		}
+	}
+
+	maxSize, err := parseSize(cmd.String("max-size"))
+	if err != nil {
+		return toolcache.DownloadOptions{}, err
+	}
+
+	cacheMaxSize, err := parseSize(cmd.String("cache-max-size"))
+	if err != nil {
+		return toolcache.DownloadOptions{}, err
+	}
+
+	return toolcache.DownloadOptions{
+		Retry:        &retry,
+		Timeout:      cmd.Duration("timeout"),
+		MaxSize:      maxSize,
+		Cache:        cmd.Bool("cache"),
+		CacheMaxSize: cacheMaxSize,
+	}, nil
+}
+
+// signatureFlags returns the flags for the public key to verify the signature of a download with.
+func signatureFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "public-key",
+			Usage: "Path to a minisign, SSH or OpenPGP public key to verify the signature of the tool with.",
+		},
+		&cli.StringFlag{
+			Name:  "signature-namespace",
+			Usage: "Namespace of an SSH signature.",
+			Value: toolcache.DefaultSSHNamespace,
+		},
+	}
+}
+
+// tlsFlags returns the flags for the CA bundle and client certificate of the shared HTTP transport.
+func tlsFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "ca-cert",
+			Usage:   "Path to a PEM bundle of CA certificates to trust in addition to the system roots.",
+			Sources: cli.EnvVars("GHACTL_CA_BUNDLE"),
+		},
+		&cli.StringFlag{
+			Name:    "client-cert",
+			Usage:   "Path to a PEM client certificate for mutual TLS.",
+			Sources: cli.EnvVars("GHACTL_CLIENT_CERT"),
+		},
+		&cli.StringFlag{
+			Name:    "client-key",
+			Usage:   "Path to the PEM private key of the client certificate. Defaults to --client-cert.",
+			Sources: cli.EnvVars("GHACTL_CLIENT_KEY"),
+		},
+	}
+}
+
+// configureTLS configures the shared HTTP transport, used for downloads and GitHub API calls, from the flags.
+func configureTLS(cmd *cli.Command) error {
+	options := httpclient.TLSOptions{
+		CACert:     cmd.String("ca-cert"),
+		ClientCert: cmd.String("client-cert"),
+		ClientKey:  cmd.String("client-key"),
+	}
+
+	slog.Debug("Configuring HTTP transport.", slog.String("caCert", options.CACert), slog.String("clientCert", options.ClientCert))
+
+	return httpclient.Configure(options)
+}
+
+// parseSize parses a size in bytes with an optional unit, such as 500MiB or 1GB.
+// An empty size is zero.
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i == -1 {
+		i = len(s)
+	}
+
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("size %s is not valid", s)
+	}
+
+	multiplier, ok := sizeUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("size %s has an unsupported unit, use B, KB, MB, GB, KiB, MiB or GiB", s)
+	}
+
+	return int64(n * float64(multiplier)), nil
+}
+
+// sizeUnits are the multipliers of the units supported by parseSize.
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+}
+
+// getDownloadGroupName returns the name of the log group for a download, without the URL's query or credentials.
+func getDownloadGroupName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "Downloading tool"
+	}
+
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	return "Downloading " + u.String()
+}
+
+// maskCredentials masks the token and header values in the GitHub Actions logs.
+func maskCredentials(w io.Writer, options toolcache.DownloadOptions) error {
+	values := []string{options.Token}
+	for _, vs := range options.Headers {
+		values = append(values, vs...)
+	}
+
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+
+		if err := core.SetSecret(w, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -10,9 +10,10 @@ import (
 func TestCmd_ExtractTar(t *testing.T) {
 	c := &Cmd{}
 
-	t.Run("errors_if_temp_dir_is_not_defined", func(t *testing.T) {
+	t.Run("errors_if_no_temp_dir_is_found", func(t *testing.T) {
 		is := is.New(t)
 		t.Setenv("RUNNER_TEMP", "")
+		t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "non-existent"))
 
 		_, err := c.ExtractTar("testdata/file.tar", false)
 
@@ -54,9 +55,10 @@ func TestCmd_ExtractTar(t *testing.T) {
 func TestCmd_ExtractZip(t *testing.T) {
 	c := &Cmd{}
 
-	t.Run("errors_if_temp_dir_is_not_defined", func(t *testing.T) {
+	t.Run("errors_if_no_temp_dir_is_found", func(t *testing.T) {
 		is := is.New(t)
 		t.Setenv("RUNNER_TEMP", "")
+		t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "non-existent"))
 
 		_, err := c.ExtractZip("testdata/file.zip")
 
@@ -46,3 +46,34 @@ func createSourceFile(t *testing.T) string {
 	}
 	return p
 }
+
+func createCacheEntry(t *testing.T, tc, tool, version, arch string) string {
+	t.Helper()
+
+	p := filepath.Join(tc, tool, version, arch)
+	if err := os.MkdirAll(p, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(p, tool), []byte("binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p+".complete", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// signatureTestdata is the directory with a minisign signature of "binary-content", tool.minisig, its public key,
+// tool.pub, and the public key of another key, other.pub.
+const signatureTestdata = "../../../testdata/signature"
+
+// readSignatureTestdata reads a file from the signature testdata directory.
+func readSignatureTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(signatureTestdata, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
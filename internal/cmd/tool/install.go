@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/urfave/cli/v3"
@@ -13,7 +14,7 @@ func (c *Cmd) installCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "install",
 		Usage: "Install and cache a tool release.",
-		Flags: []cli.Flag{
+		Flags: slices.Concat([]cli.Flag{
 			&cli.StringFlag{
 				Name:     "owner",
 				Usage:    "GitHub repository owner.",
@@ -48,7 +49,8 @@ func (c *Cmd) installCommand() *cli.Command {
 				Usage: "Add the tool directory to PATH.",
 				Value: true,
 			},
-		},
+			quietFlag(),
+		}, signatureFlags(), downloadOptionFlags(), tlsFlags()),
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			owner := cmd.String("owner")
 			repo := cmd.String("repo")
@@ -63,6 +65,20 @@ func (c *Cmd) installCommand() *cli.Command {
 			}
 			addToPath := cmd.Bool("add-to-path")
 
+			download, err := getDownloadOptions(cmd)
+			if err != nil {
+				return exitErr(err)
+			}
+
+			if err := configureTLS(cmd); err != nil {
+				return exitErr(err)
+			}
+
+			// Progress goes to stderr, so stdout only has the output.
+			if !cmd.Bool("quiet") {
+				download.Progress = cmd.Root().ErrWriter
+			}
+
 			slog.Debug("Installing tool.",
 				slog.String("owner", owner),
 				slog.String("repo", repo),
@@ -72,18 +88,23 @@ func (c *Cmd) installCommand() *cli.Command {
 				slog.String("os", osName),
 				slog.Bool("preRelease", includePreRelease),
 				slog.Bool("addToPath", addToPath),
+				slog.String("publicKey", cmd.String("public-key")),
 			)
 
 			p, err := c.Install(ctx, InstallOptions{
-				Name:              name,
-				Owner:             owner,
-				Repo:              repo,
-				Version:           version,
-				Arch:              arch,
-				OS:                osName,
-				IncludePreRelease: includePreRelease,
-				Token:             token,
-				AddToPath:         addToPath,
+				Name:               name,
+				Owner:              owner,
+				Repo:               repo,
+				Version:            version,
+				Arch:               arch,
+				OS:                 osName,
+				IncludePreRelease:  includePreRelease,
+				Token:              token,
+				AddToPath:          addToPath,
+				GhactlVersion:      cmd.Root().Version,
+				PublicKey:          cmd.String("public-key"),
+				SignatureNamespace: cmd.String("signature-namespace"),
+				Download:           download,
 			})
 			if err != nil {
 				return exitErr(err)
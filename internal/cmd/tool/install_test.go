@@ -1,17 +1,20 @@
 package tool
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
 	"github.com/matryer/is"
 
 	toolgithub "github.com/action-stars/ghactl/internal/toolkit/github"
+	"github.com/action-stars/ghactl/internal/toolkit/toolcache"
 )
 
 func TestNew_Install(t *testing.T) {
@@ -48,9 +51,14 @@ func TestNew_Install(t *testing.T) {
 		is.NoErr(installErr)
 		is.True(installedPath != "")
 
-		cachedPath, findErr := (&Cmd{}).CacheFind("bat", "", "1.2.3")
+		cachedPath, findErr := (&Cmd{}).CacheFind("bat", "", "1.2.3", toolcache.FindOptions{})
 		is.NoErr(findErr)
 		is.Equal(installedPath, cachedPath)
+
+		metadata, infoErr := (&Cmd{}).CacheInfo("bat", "", "1.2.3", toolcache.FindOptions{})
+		is.NoErr(infoErr)
+		is.Equal(metadata.Source, toolcache.MetadataSource{Owner: "sharkdp", Repo: "bat", Asset: "bat-1.2.3-linux-x64", URL: ts.URL + "/bat"})
+		is.Equal(metadata.SHA256, "37456ce54a2ef39b6c9c1d96ddc978f2edc730744bd2c9872dc1cc9ac886b00e")
 	})
 
 	t.Run("short_circuits_when_resolved_version_is_already_cached", func(t *testing.T) {
@@ -59,7 +67,7 @@ func TestNew_Install(t *testing.T) {
 		setupTempDir(t)
 
 		source := createSourceFile(t)
-		preCachedPath, cacheErr := (&Cmd{}).CacheFile(source, "bat", "bat", "1.2.3", "")
+		preCachedPath, cacheErr := (&Cmd{}).CacheFile(source, "bat", "bat", "1.2.3", "", toolcache.Metadata{})
 		is.NoErr(cacheErr)
 
 		c := &Cmd{
@@ -78,6 +86,316 @@ func TestNew_Install(t *testing.T) {
 		is.Equal(installedPath, preCachedPath)
 	})
 
+	t.Run("waits_for_concurrent_install_and_reuses_cached_tool", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		setupTempDir(t)
+
+		lock, lockErr := toolcache.LockTool(t.Context(), "bat", "1.2.3", runtime.GOARCH)
+		is.NoErr(lockErr)
+
+		c := &Cmd{
+			releaseResolver: func(_ context.Context, _, _, _, _, _, _, _ string, _ bool) (*toolgithub.ReleaseResolution, error) {
+				return &toolgithub.ReleaseResolution{
+					Version:   "1.2.3",
+					AssetName: "bat-1.2.3-linux-x64",
+					AssetURL:  "http://127.0.0.1:0/should-not-download",
+				}, nil
+			},
+		}
+
+		type result struct {
+			path string
+			err  error
+		}
+		done := make(chan result, 1)
+		go func() {
+			p, err := c.Install(context.Background(), InstallOptions{Owner: "sharkdp", Repo: "bat", Version: "latest"})
+			done <- result{path: p, err: err}
+		}()
+
+		source := createSourceFile(t)
+		preCachedPath, cacheErr := (&Cmd{}).CacheFile(source, "bat", "bat", "1.2.3", "", toolcache.Metadata{})
+		is.NoErr(cacheErr)
+		is.NoErr(lock.Close())
+
+		res := <-done
+
+		is.NoErr(res.err)                 // should not error
+		is.Equal(res.path, preCachedPath) // should reuse the tool cached while waiting
+	})
+
+	t.Run("caches_tool_for_another_os_next_to_host_tool", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		setupTempDir(t)
+
+		otherOS := "darwin"
+		if runtime.GOOS == otherOS {
+			otherOS = "linux"
+		}
+
+		source := createSourceFile(t)
+		hostPath, cacheErr := (&Cmd{}).CacheFile(source, "bat", "bat", "1.2.3", "", toolcache.Metadata{})
+		is.NoErr(cacheErr)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("binary-content"))
+		}))
+		defer ts.Close()
+
+		c := &Cmd{
+			releaseResolver: func(_ context.Context, _, _, _, _, _, osName, _ string, _ bool) (*toolgithub.ReleaseResolution, error) {
+				is.Equal(osName, otherOS)
+				return &toolgithub.ReleaseResolution{
+					Version:   "1.2.3",
+					AssetName: "bat-1.2.3-" + otherOS + "-x64",
+					AssetURL:  ts.URL + "/bat",
+				}, nil
+			},
+		}
+
+		installedPath, err := c.Install(context.Background(), InstallOptions{Owner: "sharkdp", Repo: "bat", Version: "latest", OS: otherOS, Arch: "amd64"})
+
+		is.NoErr(err)                                                 // should not error
+		is.Equal(filepath.Base(installedPath), otherOS+"-x64")        // should cache the tool for the other OS
+		is.True(installedPath != hostPath)                            // should not replace the host tool
+		is.Equal(filepath.Dir(installedPath), filepath.Dir(hostPath)) // should cache the tool next to the host tool
+
+		cachedPath, findErr := (&Cmd{}).CacheFind("bat", "", "1.2.3", toolcache.FindOptions{})
+		is.NoErr(findErr)              // should not error
+		is.Equal(cachedPath, hostPath) // should still find the host tool
+
+		metadata, infoErr := (&Cmd{}).CacheInfo("bat", otherOS+"-amd64", "1.2.3", toolcache.FindOptions{})
+		is.NoErr(infoErr)              // should not error
+		is.Equal(metadata.OS, otherOS) // should record the OS
+		is.Equal(metadata.Arch, "x64") // should record the architecture
+	})
+
+	t.Run("errors_if_download_is_larger_than_max_size", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		setupTempDir(t)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("binary-content"))
+		}))
+		defer ts.Close()
+
+		c := &Cmd{
+			releaseResolver: func(_ context.Context, _, _, _, _, _, _, _ string, _ bool) (*toolgithub.ReleaseResolution, error) {
+				return &toolgithub.ReleaseResolution{
+					Version:   "1.2.3",
+					AssetName: "bat-1.2.3-linux-x64",
+					AssetURL:  ts.URL + "/bat",
+				}, nil
+			},
+		}
+
+		_, err := c.Install(context.Background(), InstallOptions{Owner: "sharkdp", Repo: "bat", Download: toolcache.DownloadOptions{MaxSize: 4}})
+
+		is.True(err != nil)                                                           // should error
+		is.True(strings.Contains(err.Error(), "larger than the maximum size of 4 B")) // should explain the error
+	})
+
+	t.Run("verifies_signature_of_release_asset", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		setupTempDir(t)
+
+		signature := readSignatureTestdata(t, "tool.minisig")
+		publicKey := filepath.Join(signatureTestdata, "tool.pub")
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/bat.minisig" {
+				_, _ = w.Write(signature)
+				return
+			}
+			_, _ = w.Write([]byte("binary-content"))
+		}))
+		defer ts.Close()
+
+		c := &Cmd{
+			releaseResolver: func(_ context.Context, _, _, _, _, _, _, _ string, _ bool) (*toolgithub.ReleaseResolution, error) {
+				return &toolgithub.ReleaseResolution{
+					Version:       "1.2.3",
+					AssetName:     "bat-1.2.3-linux-x64",
+					AssetURL:      ts.URL + "/bat",
+					SignatureURLs: []string{ts.URL + "/bat.minisig"},
+				}, nil
+			},
+		}
+
+		installedPath, err := c.Install(context.Background(), InstallOptions{Owner: "sharkdp", Repo: "bat", PublicKey: publicKey})
+
+		is.NoErr(err)                // should not error
+		is.True(installedPath != "") // should cache the tool
+	})
+
+	t.Run("verifies_signature_of_release_asset_from_mirror", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		setupTempDir(t)
+
+		signature := readSignatureTestdata(t, "tool.minisig")
+		publicKey := filepath.Join(signatureTestdata, "tool.pub")
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/github/sharkdp/bat/releases/download/v1.2.3/bat.minisig":
+				_, _ = w.Write(signature)
+			case "/github/sharkdp/bat/releases/download/v1.2.3/bat":
+				_, _ = w.Write([]byte("binary-content"))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer ts.Close()
+
+		t.Setenv("GHACTL_MIRRORS", "https://github.invalid/="+ts.URL+"/github/")
+
+		c := &Cmd{
+			releaseResolver: func(_ context.Context, _, _, _, _, _, _, _ string, _ bool) (*toolgithub.ReleaseResolution, error) {
+				return &toolgithub.ReleaseResolution{
+					Version:       "1.2.3",
+					AssetName:     "bat-1.2.3-linux-x64",
+					AssetURL:      "https://github.invalid/sharkdp/bat/releases/download/v1.2.3/bat",
+					SignatureURLs: []string{"https://github.invalid/sharkdp/bat/releases/download/v1.2.3/bat.minisig"},
+				}, nil
+			},
+		}
+
+		_, err := c.Install(context.Background(), InstallOptions{
+			Owner:     "sharkdp",
+			Repo:      "bat",
+			PublicKey: publicKey,
+			Download:  toolcache.DownloadOptions{Retry: &toolcache.RetryPolicy{}},
+		})
+
+		is.NoErr(err) // should download the signature from the mirror
+	})
+
+	t.Run("errors_if_release_asset_signature_does_not_match", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		setupTempDir(t)
+
+		signature := readSignatureTestdata(t, "tool.minisig")
+		publicKey := filepath.Join(signatureTestdata, "other.pub")
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/bat.minisig" {
+				_, _ = w.Write(signature)
+				return
+			}
+			_, _ = w.Write([]byte("binary-content"))
+		}))
+		defer ts.Close()
+
+		c := &Cmd{
+			releaseResolver: func(_ context.Context, _, _, _, _, _, _, _ string, _ bool) (*toolgithub.ReleaseResolution, error) {
+				return &toolgithub.ReleaseResolution{
+					Version:       "1.2.3",
+					AssetName:     "bat-1.2.3-linux-x64",
+					AssetURL:      ts.URL + "/bat",
+					SignatureURLs: []string{ts.URL + "/bat.minisig"},
+				}, nil
+			},
+		}
+
+		_, err := c.Install(context.Background(), InstallOptions{Owner: "sharkdp", Repo: "bat", PublicKey: publicKey})
+
+		is.True(err != nil) // should error
+
+		cachedPath, findErr := (&Cmd{}).CacheFind("bat", "", "1.2.3", toolcache.FindOptions{})
+		is.NoErr(findErr)        // should not error
+		is.Equal(cachedPath, "") // should not cache the tool
+	})
+
+	t.Run("errors_if_release_asset_has_no_signature", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		setupTempDir(t)
+
+		publicKey := filepath.Join(signatureTestdata, "tool.pub")
+
+		c := &Cmd{
+			releaseResolver: func(_ context.Context, _, _, _, _, _, _, _ string, _ bool) (*toolgithub.ReleaseResolution, error) {
+				return &toolgithub.ReleaseResolution{
+					Version:   "1.2.3",
+					AssetName: "bat-1.2.3-linux-x64",
+					AssetURL:  "http://127.0.0.1:0/should-not-download",
+				}, nil
+			},
+		}
+
+		_, err := c.Install(context.Background(), InstallOptions{Owner: "sharkdp", Repo: "bat", PublicKey: publicKey})
+
+		is.True(err != nil)                                        // should error
+		is.True(strings.Contains(err.Error(), "has no signature")) // should explain the error
+	})
+
+	t.Run("downloads_asset_from_mirror", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		setupTempDir(t)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			is.Equal(r.URL.Path, "/github/sharkdp/bat/releases/download/v1.2.3/bat") // should download from the mirror
+			_, _ = w.Write([]byte("binary-content"))
+		}))
+		defer ts.Close()
+
+		t.Setenv("GHACTL_MIRRORS", "https://github.invalid/="+ts.URL+"/github/")
+
+		c := &Cmd{
+			releaseResolver: func(_ context.Context, _, _, _, _, _, _, _ string, _ bool) (*toolgithub.ReleaseResolution, error) {
+				return &toolgithub.ReleaseResolution{
+					Version:   "1.2.3",
+					AssetName: "bat-1.2.3-linux-x64",
+					AssetURL:  "https://github.invalid/sharkdp/bat/releases/download/v1.2.3/bat",
+				}, nil
+			},
+		}
+
+		_, err := c.Install(context.Background(), InstallOptions{Owner: "sharkdp", Repo: "bat"})
+
+		is.NoErr(err) // should not error
+
+		metadata, infoErr := (&Cmd{}).CacheInfo("bat", "", "1.2.3", toolcache.FindOptions{})
+		is.NoErr(infoErr)                                                                                // should not error
+		is.Equal(metadata.Source.URL, "https://github.invalid/sharkdp/bat/releases/download/v1.2.3/bat") // should record the release asset URL
+	})
+
+	t.Run("groups_download_progress_in_actions", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		t.Setenv("GITHUB_ACTIONS", "true")
+		setupTempDir(t)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("binary-content"))
+		}))
+		defer ts.Close()
+
+		c := &Cmd{
+			releaseResolver: func(_ context.Context, _, _, _, _, _, _, _ string, _ bool) (*toolgithub.ReleaseResolution, error) {
+				return &toolgithub.ReleaseResolution{
+					Version:   "1.2.3",
+					AssetName: "bat-1.2.3-linux-x64",
+					AssetURL:  ts.URL + "/bat",
+				}, nil
+			},
+		}
+
+		buf := new(bytes.Buffer)
+		_, err := c.Install(context.Background(), InstallOptions{Owner: "sharkdp", Repo: "bat", Download: toolcache.DownloadOptions{Progress: buf}})
+
+		is.NoErr(err)                                                                     // should not error
+		is.True(strings.HasPrefix(buf.String(), "::group::Downloading "+ts.URL+"/bat\n")) // should start a log group for the download
+		is.True(strings.HasSuffix(buf.String(), "::endgroup::\n"))                        // should end the log group
+	})
+
 	t.Run("uses_explicit_name_for_cache_path", func(t *testing.T) {
 		is := is.New(t)
 		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
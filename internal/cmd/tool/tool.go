@@ -2,12 +2,18 @@ package tool
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/url"
+	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/urfave/cli/v3"
 
@@ -34,6 +40,7 @@ func New() *cli.Command {
 			c.downloadCommand(),
 			c.extractCommand(),
 			c.versionCommand(),
+			c.whichCommand(),
 		},
 	}
 }
@@ -45,56 +52,488 @@ func (c *Cmd) resolveRelease(ctx context.Context, token, owner, repo, toolName,
 	return github.ResolveToolRelease(ctx, token, owner, repo, toolName, version, osName, arch, includePreRelease)
 }
 
-// CacheGet returns the tool cache directory path.
-func (c *Cmd) CacheGet() (string, error) {
-	return toolcache.GetToolCacheDirectory()
+// CacheGet returns the tool cache directory path and the source it was found from.
+func (c *Cmd) CacheGet() (string, string, error) {
+	return toolcache.LookupToolCacheDirectory()
 }
 
-// CacheFindAll returns all cached paths for a tool.
-func (c *Cmd) CacheFindAll(tool, arch string) ([]string, error) {
+// CacheFindAll returns all cached versions of a tool matching the version spec.
+func (c *Cmd) CacheFindAll(tool, arch, versionSpec string, options toolcache.FindOptions) ([]string, error) {
 	if arch == "" {
 		arch = runtime.GOARCH
 	}
-	return toolcache.FindAllToolVersions(tool, arch)
+	return toolcache.FindAllToolVersions(tool, arch, versionSpec, options)
 }
 
 // CacheFind returns the path to a cached tool matching the version spec.
-func (c *Cmd) CacheFind(tool, arch, versionSpec string) (string, error) {
+func (c *Cmd) CacheFind(tool, arch, versionSpec string, options toolcache.FindOptions) (string, error) {
 	if arch == "" {
 		arch = runtime.GOARCH
 	}
 	if versionSpec == "" {
 		versionSpec = "*"
 	}
-	return toolcache.FindTool(tool, arch, versionSpec)
+	return toolcache.FindTool(tool, arch, versionSpec, options)
 }
 
 // CacheDir caches a directory as a tool in the runner tool cache.
-func (c *Cmd) CacheDir(source, tool, version, arch string) (string, error) {
+func (c *Cmd) CacheDir(source, tool, version, arch string, metadata toolcache.Metadata, options toolcache.CacheDirOptions) (string, error) {
 	if arch == "" {
 		arch = runtime.GOARCH
 	}
-	return toolcache.CacheDir(source, tool, version, arch)
+	return toolcache.CacheDir(source, tool, version, arch, metadata, options)
 }
 
 // CacheFile caches a file as a tool in the runner tool cache.
-func (c *Cmd) CacheFile(source, targetName, tool, version, arch string) (string, error) {
+func (c *Cmd) CacheFile(source, targetName, tool, version, arch string, metadata toolcache.Metadata) (string, error) {
 	if arch == "" {
 		arch = runtime.GOARCH
 	}
 	if targetName == "" {
 		targetName = tool
 	}
-	return toolcache.CacheFile(source, targetName, tool, version, arch)
+	return toolcache.CacheFile(source, targetName, tool, version, arch, metadata)
 }
 
-// Download downloads a tool from a URL to a temporary directory.
-func (c *Cmd) Download(ctx context.Context, rawURL string) (string, error) {
+// CacheInfo returns the install metadata of a cached tool matching the version spec.
+func (c *Cmd) CacheInfo(tool, arch, versionSpec string, options toolcache.FindOptions) (*toolcache.Metadata, error) {
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+	if versionSpec == "" {
+		versionSpec = "*"
+	}
+	return toolcache.GetMetadata(tool, arch, versionSpec, options)
+}
+
+// CachePrune removes entries from the runner tool cache according to the prune options.
+func (c *Cmd) CachePrune(ctx context.Context, options toolcache.PruneOptions) ([]toolcache.PrunedEntry, error) {
+	return toolcache.PruneToolCache(ctx, options)
+}
+
+// CacheRemove removes cached tool versions matching the version spec.
+// If arch is empty, all architectures are removed.
+func (c *Cmd) CacheRemove(ctx context.Context, tool, arch, versionSpec string) ([]string, error) {
+	return toolcache.RemoveTool(ctx, tool, arch, versionSpec)
+}
+
+// CacheVerify verifies cached tools against the hash trees recorded when they were cached.
+// If fix is true, tools that fail verification are invalidated.
+func (c *Cmd) CacheVerify(tool string, fix bool) ([]toolcache.VerifyResult, error) {
+	return toolcache.VerifyToolCache(tool, fix)
+}
+
+// CacheExport exports cached tools matching the selectors to a tar.gz bundle.
+func (c *Cmd) CacheExport(bundlePath string, selectors []toolcache.BundleSelector) ([]toolcache.CacheEntry, error) {
+	return toolcache.ExportBundle(bundlePath, selectors)
+}
+
+// CacheImport imports the cached tools in a bundle into the runner tool cache.
+func (c *Cmd) CacheImport(ctx context.Context, bundlePath string) ([]string, error) {
+	return toolcache.ImportBundle(ctx, bundlePath)
+}
+
+// CacheDU returns the disk usage of the runner tool cache.
+// If tool is not empty, only the usage of that tool is returned.
+func (c *Cmd) CacheDU(tool string) ([]toolcache.ToolUsage, error) {
+	return toolcache.GetDiskUsage(tool)
+}
+
+// Download downloads a tool to a temporary directory or the destination in the options, verifying it against the
+// checksums in the options. The URLs, each preceded by its mirrors from GHACTL_MIRRORS, are tried in order until a
+// download succeeds.
+func (c *Cmd) Download(ctx context.Context, rawURLs []string, options toolcache.DownloadOptions) (*toolcache.DownloadResult, error) {
+	candidates, err := getDownloadURLs(rawURLs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no URL to download from")
+	}
+
+	errs := []error{}
+	for i, candidate := range candidates {
+		u, err := url.Parse(candidate.url)
+		if err != nil {
+			return nil, err
+		}
+
+		candidateOptions, err := getCandidateOptions(candidate, options)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := toolcache.DownloadTool(ctx, slog.Default(), *u, candidateOptions)
+		if err == nil {
+			// The default log level hides info logs, so a download that didn't come from the first URL is always reported.
+			if candidate.url != rawURLs[0] {
+				if core.IsGitHubActions() && options.Progress != nil {
+					if err := core.Info(options.Progress, fmt.Sprintf("Downloaded from mirror or fallback URL %s.", u.Redacted())); err != nil {
+						return nil, err
+					}
+				} else {
+					slog.Warn("Tool downloaded from a mirror or fallback URL.", slog.String("url", u.Redacted()), slog.Int("failedURLs", i))
+				}
+			}
+			return result, nil
+		}
+
+		if len(candidates) == 1 {
+			return nil, err
+		}
+
+		errs = append(errs, fmt.Errorf("downloading from %s: %w", u.Redacted(), err))
+		if ctx.Err() != nil {
+			break
+		}
+
+		slog.Warn("Download failed, trying the next URL.", slog.String("url", u.Redacted()), slog.Any("error", err))
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// downloadURL is a URL to try for a download, and the URL it's a mirror of, or itself.
+type downloadURL struct {
+	url    string
+	source string
+}
+
+// getDownloadURLs returns the URLs to try for a download, with each URL preceded by its mirrors from GHACTL_MIRRORS.
+func getDownloadURLs(rawURLs []string) ([]downloadURL, error) {
+	mirrors, err := toolcache.LookupMirrors()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []downloadURL{}
+	for _, rawURL := range rawURLs {
+		for _, u := range toolcache.GetMirrorURLs(rawURL, mirrors) {
+			if !slices.ContainsFunc(candidates, func(c downloadURL) bool { return c.url == u }) {
+				candidates = append(candidates, downloadURL{url: u, source: rawURL})
+			}
+		}
+	}
+
+	return candidates, nil
+}
+
+// getCandidateOptions returns the download options for a URL to try. The token, headers and netrc credentials are
+// meant for the host of the URL the user asked for, so they're dropped for a mirror on another host.
+func getCandidateOptions(candidate downloadURL, options toolcache.DownloadOptions) (toolcache.DownloadOptions, error) {
+	u, err := url.Parse(candidate.url)
+	if err != nil {
+		return options, err
+	}
+
+	source, err := url.Parse(candidate.source)
+	if err != nil {
+		return options, err
+	}
+
+	if strings.EqualFold(u.Host, source.Host) {
+		return options, nil
+	}
+
+	options.Token = ""
+	options.Netrc = false
+	options.Headers = nil
+
+	return options, nil
+}
+
+// DownloadManifestOptions are the options for downloading the entries of a download manifest.
+type DownloadManifestOptions struct {
+	// Parallel is the maximum number of concurrent downloads. Defaults to 1.
+	Parallel int
+	// FailFast cancels the remaining downloads after the first failure, instead of collecting every failure.
+	FailFast bool
+	// Download has the headers, credentials, retry policy and limits shared by every download.
+	// Its checksums are replaced by each entry's digest, and its destination is the default for entries without one.
+	Download toolcache.DownloadOptions
+}
+
+// DownloadManifestResult is the result of downloading a download manifest entry.
+type DownloadManifestResult struct {
+	Entry  toolcache.DownloadManifestEntry
+	Result *toolcache.DownloadResult
+	// Skipped is true if the download was canceled, or never started, because another download failed.
+	Skipped bool
+	Err     error
+}
+
+// DownloadManifest downloads the entries of a download manifest concurrently, verifying each against its SHA-256
+// digest. It returns the results in the order of the entries.
+func (c *Cmd) DownloadManifest(ctx context.Context, entries []toolcache.DownloadManifestEntry, options DownloadManifestOptions) []DownloadManifestResult {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]DownloadManifestResult, len(entries))
+	slots := make(chan struct{}, max(options.Parallel, 1))
+
+	var (
+		wg      sync.WaitGroup
+		stopped atomic.Bool
+	)
+
+	for i, entry := range entries {
+		results[i].Entry = entry
+
+		// Slots are taken in entry order, so the downloads start in the order of the manifest.
+		acquired := false
+		select {
+		case slots <- struct{}{}:
+			acquired = true
+		case <-ctx.Done():
+		}
+
+		if stopped.Load() || !acquired {
+			if acquired {
+				<-slots
+			}
+
+			if stopped.Load() {
+				results[i].Skipped = true
+			} else {
+				results[i].Err = ctx.Err()
+			}
+			continue
+		}
+
+		wg.Go(func() {
+			defer func() { <-slots }()
+
+			slog.Debug("Downloading manifest entry.", slog.String("url", entry.URL), slog.String("dest", entry.Dest))
+
+			result, err := c.downloadManifestEntry(ctx, entry, options.Download)
+			if err != nil {
+				if stopped.Load() && errors.Is(err, context.Canceled) {
+					results[i].Skipped = true
+					return
+				}
+
+				results[i].Err = err
+				if options.FailFast {
+					stopped.Store(true)
+					cancel()
+				}
+				return
+			}
+
+			results[i].Result = result
+		})
+	}
+
+	wg.Wait()
+	return results
+}
+
+// downloadManifestEntry downloads a download manifest entry with the shared download options.
+func (c *Cmd) downloadManifestEntry(ctx context.Context, entry toolcache.DownloadManifestEntry, options toolcache.DownloadOptions) (*toolcache.DownloadResult, error) {
+	checksums, err := c.DownloadChecksums(ctx, entry.URL, DownloadChecksumOptions{SHA256: entry.SHA256})
+	if err != nil {
+		return nil, err
+	}
+
+	options.Checksums = checksums
+	if entry.Dest != "" {
+		options.Dest = entry.Dest
+	}
+
+	return c.Download(ctx, []string{entry.URL}, options)
+}
+
+// DownloadCacheClear removes all the downloads from the download cache.
+// It returns the number of downloads removed and their total size.
+func (c *Cmd) DownloadCacheClear() (int, int64, error) {
+	return toolcache.ClearDownloadCache()
+}
+
+// DownloadChecksumOptions are the sources of the expected checksums of a download.
+type DownloadChecksumOptions struct {
+	SHA256 string
+	SHA512 string
+	// ChecksumURL is the URL of a checksum file with a line for the downloaded file.
+	ChecksumURL string
+	// ChecksumFile is the path to a checksum file with a line for the downloaded file.
+	ChecksumFile string
+	// Request has the headers and credentials used to download the checksum file.
+	Request toolcache.DownloadOptions
+}
+
+// DownloadChecksums returns the expected checksums of a download from a URL.
+// Lines in checksum files are matched against the last element of the URL path.
+func (c *Cmd) DownloadChecksums(ctx context.Context, rawURL string, options DownloadChecksumOptions) ([]toolcache.Checksum, error) {
+	checksums := []toolcache.Checksum{}
+
+	for _, expected := range []toolcache.Checksum{{Algorithm: toolcache.SHA256, Digest: options.SHA256}, {Algorithm: toolcache.SHA512, Digest: options.SHA512}} {
+		if expected.Digest == "" {
+			continue
+		}
+
+		checksum, err := toolcache.NewChecksum(expected.Algorithm, expected.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		checksums = append(checksums, checksum)
+	}
+
+	if options.ChecksumURL == "" && options.ChecksumFile == "" {
+		return checksums, nil
+	}
+
 	u, err := url.Parse(rawURL)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return nil, fmt.Errorf("URL %s has no file name to find in the checksum file", rawURL)
+	}
+
+	if options.ChecksumURL != "" {
+		checksumURL, err := url.Parse(options.ChecksumURL)
+		if err != nil {
+			return nil, err
+		}
+
+		checksum, err := toolcache.DownloadChecksum(ctx, slog.Default(), *checksumURL, name, options.Request)
+		if err != nil {
+			return nil, err
+		}
+
+		checksums = append(checksums, checksum)
+	}
+
+	if options.ChecksumFile != "" {
+		checksum, err := toolcache.ReadChecksumFile(options.ChecksumFile, name)
+		if err != nil {
+			return nil, err
+		}
+
+		checksums = append(checksums, checksum)
+	}
+
+	return checksums, nil
+}
+
+// DownloadSignatureOptions are the public key and the sources of the detached signature of a download.
+type DownloadSignatureOptions struct {
+	// PublicKey is the path to the minisign, SSH or OpenPGP public key. No signature is verified if it's empty.
+	PublicKey string
+	// SignatureURL is the URL of the signature file.
+	SignatureURL string
+	// SignatureFile is the path to the signature file.
+	SignatureFile string
+	// SidecarURLs are the URLs to look for the signature file at, in order, if neither the signature URL nor file is
+	// defined. Defaults to the download URL with each of toolcache.SignatureExtensions.
+	SidecarURLs []string
+	// Namespace is the namespace of an SSH signature.
+	Namespace string
+	// Request has the headers and credentials used to download the signature file.
+	Request toolcache.DownloadOptions
+}
+
+// DownloadSignature returns the detached signature of a download from a URL and the public key to verify it with, or
+// nil if no public key is defined. Signature files are downloaded through the mirrors from GHACTL_MIRRORS, as the
+// download is.
+func (c *Cmd) DownloadSignature(ctx context.Context, rawURL string, options DownloadSignatureOptions) (*toolcache.Signature, error) {
+	if options.PublicKey == "" {
+		if options.SignatureURL != "" || options.SignatureFile != "" {
+			return nil, fmt.Errorf("public key is not defined")
+		}
+		return nil, nil
+	}
+
+	publicKey, err := os.ReadFile(options.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("reading public key: %w", err)
+	}
+
+	signature := &toolcache.Signature{PublicKey: publicKey, Namespace: options.Namespace}
+
+	if options.SignatureFile != "" {
+		signature.Data, err = os.ReadFile(options.SignatureFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading signature file: %w", err)
+		}
+		return signature, nil
+	}
+
+	if options.SignatureURL != "" {
+		signature.Data, err = downloadSignatureFile(ctx, options.SignatureURL, options.Request)
+		if err != nil {
+			return nil, err
+		}
+		return signature, nil
+	}
+
+	sidecarURLs := options.SidecarURLs
+	if len(sidecarURLs) == 0 {
+		for _, ext := range toolcache.SignatureExtensions {
+			sidecarURLs = append(sidecarURLs, rawURL+ext)
+		}
+	}
+
+	for _, rawSidecarURL := range sidecarURLs {
+		signature.Data, err = downloadSignatureFile(ctx, rawSidecarURL, options.Request)
+		if errors.Is(err, toolcache.ErrSignatureNotFound) {
+			slog.Debug("Signature file not found.", slog.String("url", rawSidecarURL))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Debug("Signature file found.", slog.String("url", rawSidecarURL))
+		return signature, nil
+	}
+
+	return nil, fmt.Errorf("no signature file found for %s", rawURL)
+}
+
+// downloadSignatureFile downloads a signature file from a URL, preceded by its mirrors from GHACTL_MIRRORS, trying each
+// in order. It returns toolcache.ErrSignatureNotFound only if every URL responded that the file doesn't exist.
+func downloadSignatureFile(ctx context.Context, rawURL string, options toolcache.DownloadOptions) ([]byte, error) {
+	candidates, err := getDownloadURLs([]string{rawURL})
+	if err != nil {
+		return nil, err
 	}
-	return toolcache.DownloadTool(ctx, slog.Default(), *u)
+
+	errs := []error{}
+	for _, candidate := range candidates {
+		u, err := url.Parse(candidate.url)
+		if err != nil {
+			return nil, err
+		}
+
+		candidateOptions, err := getCandidateOptions(candidate, options)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := toolcache.DownloadSignature(ctx, slog.Default(), *u, candidateOptions)
+		if err == nil {
+			return data, nil
+		}
+
+		if !errors.Is(err, toolcache.ErrSignatureNotFound) {
+			errs = append(errs, fmt.Errorf("downloading signature from %s: %w", u.Redacted(), err))
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return nil, toolcache.ErrSignatureNotFound
 }
 
 // ExtractTar extracts a tar archive to a temporary directory.
@@ -107,6 +546,17 @@ func (c *Cmd) ExtractZip(path string) (string, error) {
 	return toolcache.ExtractZip(path)
 }
 
+// Which returns the path to an executable in a cached tool matching the version spec.
+func (c *Cmd) Which(tool, arch, versionSpec, bin string, options toolcache.FindOptions) (string, error) {
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+	if versionSpec == "" {
+		versionSpec = "*"
+	}
+	return toolcache.FindExecutable(tool, arch, versionSpec, bin, options)
+}
+
 // VersionCheck checks if a version satisfies a version spec.
 func (c *Cmd) VersionCheck(version, versionSpec string) (bool, error) {
 	return toolcache.CheckVersion(version, versionSpec)
@@ -123,6 +573,14 @@ type InstallOptions struct {
 	IncludePreRelease bool
 	Token             string
 	AddToPath         bool
+	GhactlVersion     string
+	// PublicKey is the path to the public key to verify the signature sidecar asset of the release asset with.
+	PublicKey string
+	// SignatureNamespace is the namespace of an SSH signature.
+	SignatureNamespace string
+	// Download has the retry policy, timeout and maximum size used to download the release asset.
+	// With progress, the download is in its own log group in GitHub Actions.
+	Download toolcache.DownloadOptions
 }
 
 // Install resolves, downloads, and caches a tool release. It returns the cached tool path.
@@ -156,7 +614,14 @@ func (c *Cmd) Install(ctx context.Context, options InstallOptions) (string, erro
 		return "", err
 	}
 
-	cachedPath, err := c.CacheFind(name, arch, resolution.Version)
+	// Hold the tool lock until the tool is cached, so a concurrent install of the same tool waits and then reuses it.
+	lock, err := toolcache.LockTool(ctx, name, resolution.Version, toolcache.PlatformArch(osName, arch))
+	if err != nil {
+		return "", err
+	}
+	defer lock.Close()
+
+	cachedPath, err := c.CacheFind(name, arch, resolution.Version, toolcache.FindOptions{OS: osName})
 	if err != nil {
 		return "", err
 	}
@@ -168,10 +633,52 @@ func (c *Cmd) Install(ctx context.Context, options InstallOptions) (string, erro
 		return cachedPath, nil
 	}
 
-	downloadPath, err := c.Download(ctx, resolution.AssetURL)
+	if options.PublicKey != "" {
+		if len(resolution.SignatureURLs) == 0 {
+			return "", fmt.Errorf("release asset %s has no signature", resolution.AssetName)
+		}
+
+		signature, err := c.DownloadSignature(ctx, resolution.AssetURL, DownloadSignatureOptions{
+			PublicKey:   options.PublicKey,
+			SidecarURLs: resolution.SignatureURLs,
+			Namespace:   options.SignatureNamespace,
+			Request:     options.Download,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		options.Download.Signature = signature
+	}
+
+	grouped := false
+	if options.Download.Progress != nil && core.IsGitHubActions() {
+		if err := core.StartGroup(options.Download.Progress, getDownloadGroupName(resolution.AssetURL)); err != nil {
+			return "", err
+		}
+		grouped = true
+	}
+
+	download, err := c.Download(ctx, []string{resolution.AssetURL}, options.Download)
+	if grouped {
+		err = errors.Join(err, core.EndGroup(options.Download.Progress))
+	}
 	if err != nil {
 		return "", err
 	}
+	downloadPath := download.Path
+
+	metadata := toolcache.Metadata{
+		OS: osName,
+		Source: toolcache.MetadataSource{
+			Owner: options.Owner,
+			Repo:  options.Repo,
+			Asset: resolution.AssetName,
+			URL:   resolution.AssetURL,
+		},
+		SHA256:        download.SHA256,
+		GhactlVersion: options.GhactlVersion,
+	}
 
 	assetName := strings.ToLower(resolution.AssetName)
 
@@ -187,7 +694,7 @@ func (c *Cmd) Install(ctx context.Context, options InstallOptions) (string, erro
 			return "", err
 		}
 
-		cachedPath, err = c.CacheDir(resolvedPath, name, resolution.Version, arch)
+		cachedPath, err = c.CacheDir(resolvedPath, name, resolution.Version, arch, metadata, toolcache.CacheDirOptions{Move: true})
 		if err != nil {
 			return "", err
 		}
@@ -202,7 +709,7 @@ func (c *Cmd) Install(ctx context.Context, options InstallOptions) (string, erro
 			return "", err
 		}
 
-		cachedPath, err = c.CacheDir(resolvedPath, name, resolution.Version, arch)
+		cachedPath, err = c.CacheDir(resolvedPath, name, resolution.Version, arch, metadata, toolcache.CacheDirOptions{Move: true})
 		if err != nil {
 			return "", err
 		}
@@ -217,7 +724,7 @@ func (c *Cmd) Install(ctx context.Context, options InstallOptions) (string, erro
 			return "", err
 		}
 
-		cachedPath, err = c.CacheDir(resolvedPath, name, resolution.Version, arch)
+		cachedPath, err = c.CacheDir(resolvedPath, name, resolution.Version, arch, metadata, toolcache.CacheDirOptions{Move: true})
 		if err != nil {
 			return "", err
 		}
@@ -227,7 +734,7 @@ func (c *Cmd) Install(ctx context.Context, options InstallOptions) (string, erro
 			targetName = name
 		}
 
-		cachedPath, err = c.CacheFile(downloadPath, targetName, name, resolution.Version, arch)
+		cachedPath, err = c.CacheFile(downloadPath, targetName, name, resolution.Version, arch, metadata)
 		if err != nil {
 			return "", err
 		}
@@ -273,6 +780,20 @@ func osFlag() *cli.StringFlag {
 	}
 }
 
+func strictFlag() *cli.BoolFlag {
+	return &cli.BoolFlag{
+		Name:  "strict",
+		Usage: "Only accept strict semver versions.",
+	}
+}
+
+func quietFlag() *cli.BoolFlag {
+	return &cli.BoolFlag{
+		Name:  "quiet",
+		Usage: "Don't report download progress.",
+	}
+}
+
 func toolNameFlag() *cli.StringFlag {
 	return &cli.StringFlag{
 		Name:     "name",
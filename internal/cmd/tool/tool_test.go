@@ -3,15 +3,23 @@ package tool
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/matryer/is"
 	"github.com/urfave/cli/v3"
+
+	"github.com/action-stars/ghactl/internal/toolkit/httpclient"
+	"github.com/action-stars/ghactl/internal/toolkit/toolcache"
 )
 
 func TestNew_CacheGet(t *testing.T) {
@@ -20,18 +28,38 @@ func TestNew_CacheGet(t *testing.T) {
 		tc := setupToolCache(t)
 
 		buf := new(bytes.Buffer)
+		errBuf := new(bytes.Buffer)
 		cmd := New()
 		cmd.Writer = buf
+		cmd.ErrWriter = errBuf
 
 		err := cmd.Run(context.Background(), []string{"tool", "cache", "get"})
 
-		is.NoErr(err)                                 // should not error
-		is.Equal(strings.TrimSpace(buf.String()), tc) // should output cache dir
+		is.NoErr(err)                                                               // should not error
+		is.Equal(strings.TrimSpace(buf.String()), tc)                               // should output cache dir
+		is.Equal(errBuf.String(), "Tool cache directory from RUNNER_TOOL_CACHE.\n") // should report the source on stderr
+	})
+
+	t.Run("outputs_tool_cache_directory_and_source_as_json", func(t *testing.T) {
+		is := is.New(t)
+		tc := setupToolCache(t)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "get", "--json"})
+
+		is.NoErr(err) // should not error
+
+		var result map[string]string
+		is.NoErr(json.Unmarshal(buf.Bytes(), &result))                                 // should output JSON
+		is.Equal(result, map[string]string{"path": tc, "source": "RUNNER_TOOL_CACHE"}) // should output cache dir and source
 	})
 
-	t.Run("errors_when_env_not_set", func(t *testing.T) {
+	t.Run("errors_if_tool_cache_dir_does_not_exist", func(t *testing.T) {
 		is := is.New(t)
-		t.Setenv("RUNNER_TOOL_CACHE", "")
+		t.Setenv("RUNNER_TOOL_CACHE", filepath.Join(t.TempDir(), "non-existent"))
 
 		buf := new(bytes.Buffer)
 		cmd := New()
@@ -105,6 +133,112 @@ func TestNew_CacheFind(t *testing.T) {
 		})
 	})
 
+	t.Run("finds_loose_version_unless_strict", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		p := createCacheEntry(t, tc, "my-tool", "v1.22", "x64")
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "find", "--name", "my-tool", "--arch", "amd64", "--version", "^1.22.0"})
+
+		is.NoErr(err)                                // should not error
+		is.Equal(strings.TrimSpace(buf.String()), p) // should find loose version
+
+		buf.Reset()
+		cmd = New()
+		cmd.Writer = buf
+
+		err = cmd.Run(context.Background(), []string{"tool", "cache", "find", "--name", "my-tool", "--arch", "amd64", "--version", "^1.22.0", "--strict"})
+
+		is.NoErr(err)          // should not error
+		is.Equal(buf.Len(), 0) // should ignore loose version
+	})
+
+	t.Run("finds_prerelease_when_included", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		stable := createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+		rc := createCacheEntry(t, tc, "my-tool", "1.1.0-rc.1", "x64")
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "find", "--name", "my-tool", "--arch", "amd64", "--version", "^1.0.0"})
+
+		is.NoErr(err)                                     // should not error
+		is.Equal(strings.TrimSpace(buf.String()), stable) // should ignore prerelease
+
+		buf.Reset()
+		cmd = New()
+		cmd.Writer = buf
+
+		err = cmd.Run(context.Background(), []string{"tool", "cache", "find", "--name", "my-tool", "--arch", "amd64", "--version", "^1.0.0", "--include-prerelease"})
+
+		is.NoErr(err)                                 // should not error
+		is.Equal(strings.TrimSpace(buf.String()), rc) // should find newest prerelease
+
+		buf.Reset()
+		cmd = New()
+		cmd.Writer = buf
+
+		err = cmd.Run(context.Background(), []string{"tool", "cache", "find", "--name", "my-tool", "--arch", "amd64", "--version", "^1.0.0", "--include-prerelease", "--prefer", "stable"})
+
+		is.NoErr(err)                                     // should not error
+		is.Equal(strings.TrimSpace(buf.String()), stable) // should prefer stable version
+	})
+
+	t.Run("finds_tool_for_os", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+
+		otherOS := "darwin"
+		if runtime.GOOS == otherOS {
+			otherOS = "linux"
+		}
+
+		host := createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+		other := createCacheEntry(t, tc, "my-tool", "1.0.0", otherOS+"-x64")
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "find", "--name", "my-tool", "--arch", "amd64"})
+
+		is.NoErr(err)                                   // should not error
+		is.Equal(strings.TrimSpace(buf.String()), host) // should find the host tool
+
+		buf.Reset()
+		cmd = New()
+		cmd.Writer = buf
+
+		err = cmd.Run(context.Background(), []string{"tool", "cache", "find", "--name", "my-tool", "--arch", "amd64", "--os", otherOS})
+
+		is.NoErr(err)                                    // should not error
+		is.Equal(strings.TrimSpace(buf.String()), other) // should find the tool for the OS
+	})
+
+	t.Run("errors_for_unsupported_preference", func(t *testing.T) {
+		is := is.New(t)
+		setupToolCache(t)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "find", "--name", "test-tool", "--arch", "amd64", "--prefer", "oldest"})
+
+		is.True(err != nil) // should error
+	})
+
 	t.Run("outputs_found_tool_path", func(t *testing.T) {
 		is := is.New(t)
 		setupToolCache(t)
@@ -134,24 +268,920 @@ func TestNew_CacheFind(t *testing.T) {
 	})
 }
 
-func TestNew_Download(t *testing.T) {
-	t.Run("outputs_downloaded_file_path", func(t *testing.T) {
+func TestNew_CacheAdd(t *testing.T) {
+	t.Run("normalizes_version", func(t *testing.T) {
 		is := is.New(t)
-		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-			fmt.Fprint(w, "tool-binary")
-		}))
-		defer ts.Close()
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		source := createSourceDir(t)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "add", "dir", "--source", source, "--name", "my-tool", "--version", "v1.22", "--arch", "amd64"})
+
+		is.NoErr(err)                                                                            // should not error
+		is.Equal(strings.TrimSpace(buf.String()), filepath.Join(tc, "my-tool", "1.22.0", "x64")) // should write normalized version
+	})
+
+	t.Run("keeps_version_when_strict", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		source := createSourceFile(t)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "add", "file", "--source", source, "--name", "my-tool", "--version", "v1.22", "--arch", "amd64", "--strict"})
+
+		is.NoErr(err)                                                                           // should not error
+		is.Equal(strings.TrimSpace(buf.String()), filepath.Join(tc, "my-tool", "v1.22", "x64")) // should write version as given
+	})
+
+	t.Run("errors_for_invalid_version", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		source := createSourceFile(t)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "add", "file", "--source", source, "--name", "my-tool", "--version", "bad"})
+
+		is.True(err != nil) // should error
+	})
+}
+
+func TestNew_CacheInfo(t *testing.T) {
+	t.Run("outputs_tool_metadata_as_json", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		source := createSourceFile(t)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "add", "file", "--source", source, "--name", "my-tool", "--version", "1.0.0", "--arch", "amd64"})
+		is.NoErr(err) // should not error
+
+		buf.Reset()
+		cmd = New()
+		cmd.Writer = buf
+
+		err = cmd.Run(context.Background(), []string{"tool", "cache", "info", "--name", "my-tool", "--version", "1.0.0", "--arch", "amd64"})
+
+		is.NoErr(err) // should not error
+
+		var metadata toolcache.Metadata
+		is.NoErr(json.Unmarshal(buf.Bytes(), &metadata)) // should output json
+		is.Equal(metadata.Tool, "my-tool")               // should match tool
+		is.Equal(metadata.Arch, "x64")                   // should match arch
+	})
+
+	t.Run("outputs_metadata_of_tool_for_os", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		source := createSourceFile(t)
+		otherOS := "darwin"
+		if runtime.GOOS == otherOS {
+			otherOS = "linux"
+		}
+
+		_, err := (&Cmd{}).CacheFile(source, "my-tool", "my-tool", "1.0.0", otherOS+"-amd64", toolcache.Metadata{OS: otherOS})
+		is.NoErr(err) // should not error
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err = cmd.Run(context.Background(), []string{"tool", "cache", "info", "--name", "my-tool", "--version", "1.0.0", "--arch", "amd64", "--os", otherOS})
+
+		is.NoErr(err) // should not error
+
+		var metadata toolcache.Metadata
+		is.NoErr(json.Unmarshal(buf.Bytes(), &metadata)) // should output json
+		is.Equal(metadata.OS, otherOS)                   // should match os
+	})
+}
+
+func TestNew_CacheRemove(t *testing.T) {
+	t.Run("outputs_removed_entries", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		p := createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "remove", "--name", "my-tool", "--version", "1.0.0"})
+
+		is.NoErr(err)                                // should not error
+		is.Equal(strings.TrimSpace(buf.String()), p) // should output removed path
+
+		_, statErr := os.Stat(filepath.Join(tc, "my-tool"))
+		is.True(os.IsNotExist(statErr)) // should remove empty tool dir
+	})
+}
+
+func TestNew_CachePrune(t *testing.T) {
+	t.Run("outputs_removed_entries", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		old := createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+		createCacheEntry(t, tc, "my-tool", "1.1.0", "x64")
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "prune", "--keep-newest", "1"})
+
+		is.NoErr(err) // should not error
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		is.Equal(lines, []string{old + " (6 B)", "Removed 1 entries, freeing 6 B."}) // should output removed entries
+
+		_, statErr := os.Stat(old)
+		is.True(os.IsNotExist(statErr)) // should remove entry
+	})
+
+	t.Run("does_not_remove_entries_on_dry_run", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		old := createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+		createCacheEntry(t, tc, "my-tool", "1.1.0", "x64")
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "prune", "--keep-newest", "1", "--dry-run"})
+
+		is.NoErr(err)                                                                   // should not error
+		is.True(strings.Contains(buf.String(), "Would remove 1 entries, freeing 6 B.")) // should output summary
+
+		_, statErr := os.Stat(old)
+		is.NoErr(statErr) // should keep entry
+	})
+
+	t.Run("errors_when_no_policy_is_defined", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "prune"})
+
+		is.True(err != nil) // should error
+	})
+}
+
+func TestNew_CacheVerify(t *testing.T) {
+	t.Run("errors_and_outputs_modified_files", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		source := createSourceDir(t)
+
+		p, err := (&Cmd{}).CacheDir(source, "my-tool", "1.0.0", "amd64", toolcache.Metadata{}, toolcache.CacheDirOptions{})
+		is.NoErr(err) // should not error
+		is.NoErr(os.WriteFile(filepath.Join(p, "tool-binary"), []byte("tampered"), 0o755))
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err = cmd.Run(context.Background(), []string{"tool", "cache", "verify"})
+
+		is.True(err != nil)                                                                    // should error
+		is.Equal(strings.TrimSpace(buf.String()), "modified "+filepath.Join(p, "tool-binary")) // should output modified file
+	})
+
+	t.Run("invalidates_modified_tools_with_fix", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		source := createSourceDir(t)
+
+		p, err := (&Cmd{}).CacheDir(source, "my-tool", "1.0.0", "amd64", toolcache.Metadata{}, toolcache.CacheDirOptions{})
+		is.NoErr(err) // should not error
+		is.NoErr(os.Remove(filepath.Join(p, "tool-binary")))
 
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err = cmd.Run(context.Background(), []string{"tool", "cache", "verify", "--fix"})
+
+		is.NoErr(err)                                             // should not error
+		is.True(strings.Contains(buf.String(), "invalidated "+p)) // should output invalidated tool
+		found, _ := (&Cmd{}).CacheFind("my-tool", "amd64", "1.0.0", toolcache.FindOptions{})
+		is.Equal(found, "") // should no longer find the tool
+	})
+}
+
+func TestNew_CacheExport(t *testing.T) {
+	t.Run("errors_if_versions_do_not_match_names", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		setupTempDir(t)
+
+		cmd := New()
+		cmd.Writer = new(bytes.Buffer)
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "export", "--name", "a", "--name", "b", "--name", "c", "--version", "1.0.0", "--version", "2.0.0", "-o", filepath.Join(t.TempDir(), "bundle.tar.gz")})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("outputs_exported_entries", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
 		setupTempDir(t)
+		a := createCacheEntry(t, tc, "tool-a", "1.0.0", "x64")
+		b := createCacheEntry(t, tc, "tool-b", "2.0.0", "x64")
+		bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
 
 		buf := new(bytes.Buffer)
 		cmd := New()
 		cmd.Writer = buf
 
-		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool"})
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "export", "--name", "tool-a", "--name", "tool-b", "--version", "*", "-o", bundle})
 
-		is.NoErr(err)          // should not error
-		is.True(buf.Len() > 0) // should output path
+		is.NoErr(err)                                       // should not error
+		is.Equal(strings.TrimSpace(buf.String()), a+"\n"+b) // should output exported paths
+
+		_, statErr := os.Stat(bundle)
+		is.NoErr(statErr) // should write the bundle
+	})
+}
+
+func TestNew_CacheImport(t *testing.T) {
+	t.Run("errors_if_bundle_is_not_defined", func(t *testing.T) {
+		is := is.New(t)
+
+		cmd := New()
+		cmd.Writer = new(bytes.Buffer)
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "import"})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("outputs_imported_entries", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		setupTempDir(t)
+		p := createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+		bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+		_, err := (&Cmd{}).CacheExport(bundle, []toolcache.BundleSelector{{Tool: "my-tool", VersionSpec: "*"}})
+		is.NoErr(err) // should export
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err = cmd.Run(context.Background(), []string{"tool", "cache", "import", bundle})
+
+		is.NoErr(err)                                                            // should not error
+		is.True(strings.HasSuffix(strings.TrimSpace(buf.String()), p[len(tc):])) // should output imported path
+	})
+}
+
+func TestNew_CacheDU(t *testing.T) {
+	t.Run("outputs_disk_usage", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		t.Setenv("GITHUB_ACTIONS", "")
+		createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+		p := createCacheEntry(t, tc, "my-tool", "1.1.0", "x64")
+		if err := os.WriteFile(filepath.Join(p, "data"), []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "du"})
+
+		is.NoErr(err) // should not error
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		is.Equal(lines, []string{"my-tool (16 B)", "  1.1.0/x64 (10 B)", "  1.0.0/x64 (6 B)", "Total 16 B."}) // should output usage largest first
+	})
+
+	t.Run("outputs_disk_usage_as_json", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		t.Setenv("GITHUB_ACTIONS", "")
+		p := createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "du", "--json"})
+
+		is.NoErr(err) // should not error
+
+		want := []toolcache.ToolUsage{{
+			Tool:    "my-tool",
+			Size:    6,
+			Entries: []toolcache.EntryUsage{{Version: "1.0.0", Arch: "x64", Path: p, Complete: true, Size: 6}},
+		}}
+
+		var result cacheDUResult
+		is.NoErr(json.Unmarshal(buf.Bytes(), &result)) // should output JSON
+		is.Equal(result.Size, int64(6))                // should output total size
+		is.Equal(result.Tools, want)                   // should output usage
+	})
+
+	t.Run("writes_summary_in_github_actions", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+		summary := filepath.Join(t.TempDir(), "summary.md")
+		t.Setenv("GITHUB_ACTIONS", "true")
+		t.Setenv("GITHUB_STEP_SUMMARY", summary)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "cache", "du"})
+
+		is.NoErr(err) // should not error
+
+		data, err := os.ReadFile(summary)
+		is.NoErr(err)                                                              // should write summary
+		is.True(strings.Contains(string(data), "| my-tool | 1.0.0 | x64 | 6 B |")) // should write usage table
+		is.True(strings.Contains(string(data), "| **Total** | | | **6 B** |"))     // should write total
+	})
+}
+
+func TestNew_Download(t *testing.T) {
+	t.Run("outputs_downloaded_file_path", func(t *testing.T) {
+		is := is.New(t)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "tool-binary")
+		}))
+		defer ts.Close()
+
+		setupTempDir(t)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool"})
+
+		is.NoErr(err)          // should not error
+		is.True(buf.Len() > 0) // should output path
+	})
+
+	t.Run("verifies_checksum_file_and_sets_digest_output", func(t *testing.T) {
+		is := is.New(t)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "tool-binary")
+		}))
+		defer ts.Close()
+
+		setupTempDir(t)
+		checksumFile := filepath.Join(t.TempDir(), "checksums.txt")
+		if err := os.WriteFile(checksumFile, []byte("03ebe1da0849c3d6298aec01995cf1486376af6fb09f8ff9498b7d7f73bc861d  tool.tar.gz\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		output := filepath.Join(t.TempDir(), "output")
+		t.Setenv("GITHUB_ACTIONS", "true")
+		t.Setenv("GITHUB_OUTPUT", output)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool.tar.gz", "--checksum-file", checksumFile})
+
+		is.NoErr(err) // should not error
+
+		data, err := os.ReadFile(output)
+		is.NoErr(err)                                                                                               // should write outputs
+		is.True(strings.Contains(string(data), "03ebe1da0849c3d6298aec01995cf1486376af6fb09f8ff9498b7d7f73bc861d")) // should output the digest
+	})
+
+	t.Run("errors_on_checksum_mismatch", func(t *testing.T) {
+		is := is.New(t)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "tool-binary")
+		}))
+		defer ts.Close()
+
+		temp := t.TempDir()
+		t.Setenv("RUNNER_TEMP", temp)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool", "--sha256", strings.Repeat("0", 64)})
+
+		is.True(err != nil) // should error
+
+		items, _ := os.ReadDir(temp)
+		is.Equal(len(items), 0) // should delete the download
+	})
+
+	t.Run("sends_credentials_and_masks_them", func(t *testing.T) {
+		is := is.New(t)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret-token" || r.Header.Get("X-Api-Key") != "secret-key" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, "tool-binary")
+		}))
+		defer ts.Close()
+
+		setupTempDir(t)
+		t.Setenv("GITHUB_ACTIONS", "true")
+		t.Setenv("GITHUB_OUTPUT", filepath.Join(t.TempDir(), "output"))
+
+		buf := new(bytes.Buffer)
+		errBuf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ErrWriter = errBuf
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool", "--token", "secret-token", "--header", "X-Api-Key: secret-key", "--header", "Accept: application/octet-stream"})
+
+		is.NoErr(err)                                                                      // should not error
+		is.True(strings.Contains(errBuf.String(), "::add-mask::secret-token"))             // should mask the token
+		is.True(strings.Contains(errBuf.String(), "::add-mask::secret-key"))               // should mask the header value
+		is.True(strings.Contains(errBuf.String(), "::add-mask::application/octet-stream")) // should mask every header value
+		is.True(!strings.Contains(buf.String(), "secret"))                                 // should not output credentials
+	})
+
+	t.Run("outputs_json_and_saves_to_dest", func(t *testing.T) {
+		is := is.New(t)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/gzip")
+			fmt.Fprint(w, "tool-binary")
+		}))
+		defer ts.Close()
+
+		setupTempDir(t)
+		dest := t.TempDir()
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool.tar.gz", "--dest", dest, "--json"})
+
+		is.NoErr(err) // should not error
+
+		var got downloadResult
+		is.NoErr(json.Unmarshal(buf.Bytes(), &got))                                              // should output JSON
+		is.Equal(got.Path, filepath.Join(dest, "tool.tar.gz"))                                   // should save to the destination
+		is.Equal(got.MimeType, "application/gzip")                                               // should output the MIME type
+		is.Equal(got.Size, int64(len("tool-binary")))                                            // should output the size
+		is.Equal(got.SHA256, "03ebe1da0849c3d6298aec01995cf1486376af6fb09f8ff9498b7d7f73bc861d") // should output the digest
+	})
+
+	t.Run("verifies_sidecar_signature_and_outputs_format", func(t *testing.T) {
+		is := is.New(t)
+		signature := readSignatureTestdata(t, "tool.minisig")
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/tool.tar.gz":
+				fmt.Fprint(w, "binary-content")
+			case "/tool.tar.gz.minisig":
+				_, _ = w.Write(signature)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer ts.Close()
+
+		setupTempDir(t)
+		publicKey := filepath.Join(signatureTestdata, "tool.pub")
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool.tar.gz", "--public-key", publicKey, "--json"})
+
+		is.NoErr(err) // should not error
+
+		var got downloadResult
+		is.NoErr(json.Unmarshal(buf.Bytes(), &got)) // should output JSON
+		is.Equal(got.Signature, "minisign")         // should output the verified signature format
+	})
+
+	t.Run("errors_on_invalid_signature_file", func(t *testing.T) {
+		is := is.New(t)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "binary-content")
+		}))
+		defer ts.Close()
+
+		temp := t.TempDir()
+		t.Setenv("RUNNER_TEMP", temp)
+		signatureFile := filepath.Join(signatureTestdata, "tool.minisig")
+		publicKey := filepath.Join(signatureTestdata, "other.pub")
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool", "--public-key", publicKey, "--signature-file", signatureFile})
+
+		is.True(err != nil) // should error
+
+		items, _ := os.ReadDir(temp)
+		is.Equal(len(items), 0) // should delete the download
+	})
+
+	t.Run("errors_if_dest_exists_without_overwrite", func(t *testing.T) {
+		is := is.New(t)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "tool-binary")
+		}))
+		defer ts.Close()
+
+		setupTempDir(t)
+		dest := filepath.Join(t.TempDir(), "tool")
+		if err := os.WriteFile(dest, []byte("existing"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool", "--dest", dest})
+
+		is.True(err != nil) // should error
+
+		buf.Reset()
+		cmd = New()
+		cmd.Writer = buf
+
+		err = cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool", "--dest", dest, "--overwrite"})
+
+		is.NoErr(err)                                   // should not error
+		is.Equal(strings.TrimSpace(buf.String()), dest) // should output the destination
+
+		data, _ := os.ReadFile(dest)
+		is.Equal(string(data), "tool-binary") // should overwrite the file
+	})
+
+	t.Run("reports_progress_in_a_group", func(t *testing.T) {
+		is := is.New(t)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "tool-binary")
+		}))
+		defer ts.Close()
+
+		setupTempDir(t)
+		t.Setenv("GITHUB_ACTIONS", "true")
+		t.Setenv("GITHUB_OUTPUT", filepath.Join(t.TempDir(), "output"))
+
+		buf := new(bytes.Buffer)
+		errBuf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ErrWriter = errBuf
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool"})
+
+		is.NoErr(err) // should not error
+
+		lines := strings.Split(strings.TrimSpace(errBuf.String()), "\n")
+		is.Equal(len(lines), 3)                                         // should write the group and progress
+		is.Equal(lines[0], "::group::Downloading "+ts.URL+"/tool")      // should start a group
+		is.True(strings.HasPrefix(lines[1], "Downloaded 11 B of 11 B")) // should report progress
+		is.Equal(lines[2], "::endgroup::")                              // should end the group
+		is.True(!strings.Contains(buf.String(), "Downloaded"))          // should not write progress to stdout
+	})
+
+	t.Run("does_not_report_progress_when_quiet", func(t *testing.T) {
+		is := is.New(t)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "tool-binary")
+		}))
+		defer ts.Close()
+
+		setupTempDir(t)
+
+		buf := new(bytes.Buffer)
+		errBuf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ErrWriter = errBuf
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool", "--quiet"})
+
+		is.NoErr(err)             // should not error
+		is.Equal(errBuf.Len(), 0) // should not report progress
+	})
+
+	t.Run("errors_if_larger_than_max_size_from_env", func(t *testing.T) {
+		is := is.New(t)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "tool-binary")
+		}))
+		defer ts.Close()
+
+		setupTempDir(t)
+		t.Setenv("GHACTL_DOWNLOAD_MAX_SIZE", "10B")
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool", "--quiet"})
+
+		is.True(err != nil)                                                            // should error
+		is.True(strings.Contains(err.Error(), "larger than the maximum size of 10 B")) // should explain the error
+	})
+
+	t.Run("errors_on_invalid_retry_waits", func(t *testing.T) {
+		is := is.New(t)
+		setupTempDir(t)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", "http://example.com/tool", "--retry-wait-min", "1m", "--retry-wait-max", "1s"})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("errors_on_invalid_header", func(t *testing.T) {
+		is := is.New(t)
+		setupTempDir(t)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", "http://example.com/tool", "--header", "X-Api-Key"})
+
+		is.True(err != nil) // should error
+	})
+	t.Run("trusts_ca_cert", func(t *testing.T) {
+		is := is.New(t)
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "tool-binary")
+		}))
+		defer ts.Close()
+
+		setupTempDir(t)
+		t.Cleanup(func() { _ = httpclient.Configure(httpclient.TLSOptions{}) })
+		caCert := filepath.Join(t.TempDir(), "ca.pem")
+		if err := os.WriteFile(caCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool", "--ca-cert", caCert, "--quiet"})
+
+		is.NoErr(err)          // should trust the CA certificate
+		is.True(buf.Len() > 0) // should output path
+	})
+
+	t.Run("errors_on_invalid_ca_cert", func(t *testing.T) {
+		is := is.New(t)
+		setupTempDir(t)
+		t.Setenv("GHACTL_CA_BUNDLE", filepath.Join(t.TempDir(), "missing.pem"))
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--url", "http://example.com/tool"})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("errors_if_url_is_not_defined", func(t *testing.T) {
+		is := is.New(t)
+		setupTempDir(t)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "download"})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("restores_download_from_cache", func(t *testing.T) {
+		is := is.New(t)
+		var requests atomic.Int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests.Add(1)
+			fmt.Fprint(w, "tool-binary")
+		}))
+		defer ts.Close()
+
+		setupTempDir(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+
+		for _, want := range []bool{false, true} {
+			buf := new(bytes.Buffer)
+			cmd := New()
+			cmd.Writer = buf
+
+			err := cmd.Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool", "--sha256", "03ebe1da0849c3d6298aec01995cf1486376af6fb09f8ff9498b7d7f73bc861d", "--cache", "--json", "--quiet"})
+
+			is.NoErr(err) // should not error
+
+			var result downloadResult
+			is.NoErr(json.Unmarshal(buf.Bytes(), &result)) // should output JSON
+			is.Equal(result.Cached, want)                  // should report whether the download was cached
+		}
+
+		is.Equal(requests.Load(), int32(1)) // should only download once
+	})
+}
+
+func TestNew_DownloadManifest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, "tool-binary")
+	}))
+	defer ts.Close()
+
+	writeManifest := func(t *testing.T, entries ...toolcache.DownloadManifestEntry) string {
+		t.Helper()
+
+		data, err := json.Marshal(entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := filepath.Join(t.TempDir(), "downloads.json")
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return p
+	}
+
+	t.Run("outputs_results_table", func(t *testing.T) {
+		is := is.New(t)
+		setupTempDir(t)
+		dest := t.TempDir()
+		manifest := writeManifest(t,
+			toolcache.DownloadManifestEntry{URL: ts.URL + "/tool-a", SHA256: "03ebe1da0849c3d6298aec01995cf1486376af6fb09f8ff9498b7d7f73bc861d"},
+			toolcache.DownloadManifestEntry{URL: ts.URL + "/tool-b"},
+		)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--manifest", manifest, "--dest", dest})
+
+		is.NoErr(err) // should not error
+
+		lines := strings.Split(buf.String(), "\n")
+		is.True(strings.HasPrefix(lines[0], "STATUS"))                                                                 // should output a header
+		is.True(strings.Contains(lines[1], "downloaded") && strings.Contains(lines[1], filepath.Join(dest, "tool-a"))) // should output the first download
+		is.True(strings.Contains(lines[2], "downloaded") && strings.Contains(lines[2], filepath.Join(dest, "tool-b"))) // should output the second download
+	})
+
+	t.Run("outputs_json_and_errors_on_failure", func(t *testing.T) {
+		is := is.New(t)
+		setupTempDir(t)
+		manifest := writeManifest(t,
+			toolcache.DownloadManifestEntry{URL: ts.URL + "/missing"},
+			toolcache.DownloadManifestEntry{URL: ts.URL + "/tool"},
+		)
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--manifest", manifest, "--json", "--retries", "0"})
+
+		is.True(err != nil) // should error
+
+		var results []downloadManifestResult
+		is.NoErr(json.Unmarshal(buf.Bytes(), &results)) // should output JSON
+		is.Equal(len(results), 2)                       // should output every entry
+		is.Equal(results[0].Status, "failed")           // should report the failure
+		is.True(results[0].Error != "")                 // should output the error
+		is.Equal(results[1].Status, "downloaded")       // should still download the other entry
+	})
+
+	t.Run("errors_if_url_is_also_defined", func(t *testing.T) {
+		is := is.New(t)
+		setupTempDir(t)
+		manifest := writeManifest(t, toolcache.DownloadManifestEntry{URL: ts.URL + "/tool"})
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--manifest", manifest, "--url", ts.URL + "/tool"})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("errors_on_invalid_parallel", func(t *testing.T) {
+		is := is.New(t)
+		setupTempDir(t)
+		manifest := writeManifest(t, toolcache.DownloadManifestEntry{URL: ts.URL + "/tool"})
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "--manifest", manifest, "--parallel", "0"})
+
+		is.True(err != nil) // should error
+	})
+}
+
+func TestNew_DownloadCacheClear(t *testing.T) {
+	t.Run("outputs_removed_downloads", func(t *testing.T) {
+		is := is.New(t)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "tool-binary")
+		}))
+		defer ts.Close()
+
+		setupTempDir(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+
+		err := New().Run(context.Background(), []string{"tool", "download", "--url", ts.URL + "/tool", "--cache", "--quiet"})
+		is.NoErr(err) // should not error
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err = cmd.Run(context.Background(), []string{"tool", "download", "cache", "clear"})
+
+		is.NoErr(err)                                                                          // should not error
+		is.Equal(strings.TrimSpace(buf.String()), "Removed 1 cached downloads, freeing 11 B.") // should output the removed downloads
+	})
+
+	t.Run("outputs_nothing_removed_when_cache_is_empty", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "download", "cache", "clear"})
+
+		is.NoErr(err)                                                                         // should not error
+		is.Equal(strings.TrimSpace(buf.String()), "Removed 0 cached downloads, freeing 0 B.") // should output nothing removed
 	})
 }
 
@@ -0,0 +1,51 @@
+package tool
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/action-stars/ghactl/internal/toolkit/toolcache"
+)
+
+func (c *Cmd) whichCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "which",
+		Usage: "Find the path to an executable in a cached tool.",
+		Flags: []cli.Flag{
+			toolNameFlag(),
+			archFlag(),
+			&cli.StringFlag{
+				Name:  "version",
+				Usage: "Version spec of the tool.",
+			},
+			&cli.StringFlag{
+				Name:  "bin",
+				Usage: "Name of the executable. Defaults to the only executable, or the one named after the tool.",
+			},
+			strictFlag(),
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			tool := cmd.String("name")
+			arch := defaultArch(cmd.String("arch"))
+			versionSpec := cmd.String("version")
+			bin := cmd.String("bin")
+			options := toolcache.FindOptions{Strict: cmd.Bool("strict")}
+
+			slog.Debug("Finding tool executable.", slog.String("tool", tool), slog.String("versionSpec", versionSpec), slog.String("bin", bin))
+
+			p, err := c.Which(tool, arch, versionSpec, bin, options)
+			if err != nil {
+				return exitErr(err)
+			}
+
+			if err := writeOutput(cmd, p); err != nil {
+				return err
+			}
+
+			slog.Debug("Tool executable found.", slog.String("tool", tool), slog.String("path", p))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/urfave/cli/v3"
+
+	"github.com/action-stars/ghactl/internal/toolkit/toolcache"
+)
+
+func TestCmd_Which(t *testing.T) {
+	c := &Cmd{}
+
+	t.Run("errors_if_tool_is_not_cached", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+
+		_, err := c.Which("my-tool", "amd64", "", "", toolcache.FindOptions{})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("returns_the_tool_executable", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		p := createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+
+		got, err := c.Which("my-tool", "amd64", "", "", toolcache.FindOptions{})
+
+		is.NoErr(err)                              // should not error
+		is.Equal(got, filepath.Join(p, "my-tool")) // should return the executable
+	})
+}
+
+func TestNew_Which(t *testing.T) {
+	t.Run("outputs_the_executable_path", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		p := createCacheEntry(t, tc, "my-tool", "1.0.0", "x64")
+		is.NoErr(os.WriteFile(filepath.Join(p, "helper"), []byte("helper"), 0o755)) // should create helper
+
+		buf := new(bytes.Buffer)
+		cmd := New()
+		cmd.Writer = buf
+
+		err := cmd.Run(context.Background(), []string{"tool", "which", "--name", "my-tool", "--arch", "amd64", "--bin", "helper"})
+
+		is.NoErr(err)                                                         // should not error
+		is.Equal(strings.TrimSpace(buf.String()), filepath.Join(p, "helper")) // should output the executable path
+	})
+
+	t.Run("errors_if_there_are_several_candidates", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv("RUNNER_TOOL_CACHE", tc)
+		p := filepath.Join(tc, "my-tool", "1.0.0", "x64")
+		is.NoErr(os.MkdirAll(p, 0o755))                                   // should create tool dir
+		is.NoErr(os.WriteFile(filepath.Join(p, "a"), []byte("a"), 0o755)) // should create executable
+		is.NoErr(os.WriteFile(filepath.Join(p, "b"), []byte("b"), 0o755)) // should create executable
+		is.NoErr(os.WriteFile(p+".complete", nil, 0o644))                 // should create marker
+
+		cmd := New()
+		cmd.Writer = new(bytes.Buffer)
+		cmd.ExitErrHandler = func(_ context.Context, _ *cli.Command, _ error) {}
+
+		err := cmd.Run(context.Background(), []string{"tool", "which", "--name", "my-tool", "--arch", "amd64"})
+
+		is.True(err != nil) // should error
+	})
+}
@@ -3,8 +3,11 @@ package fileio
 import (
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 // DirExists checks if a directory exists.
@@ -24,3 +27,282 @@ func DirExists(p string) (bool, error) {
 	}
 	return true, nil
 }
+
+// DirSize returns the total size in bytes of the regular files under a directory.
+// Symlinks are not followed or counted: a symlink inside the directory points at a file that's already counted, and
+// its own size is negligible.
+func DirSize(p string) (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(p, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		size += fi.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// CopyDir copies the contents of the src directory into the dest directory, creating it if it doesn't exist.
+// File and directory modes are preserved, and symlinks are recreated as relative symlinks.
+// It returns an error if a symlink escapes src or if src contains anything other than directories, regular files and symlinks.
+func CopyDir(src, dest string) error {
+	// Symlink targets are checked against an absolute src, as absolute targets can't be made relative to a relative path.
+	src, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return err
+	}
+
+	// Directory modes are applied once their contents are copied, so read-only directories can be copied.
+	type dirMode struct {
+		path string
+		mode fs.FileMode
+	}
+	dirs := []dirMode{}
+
+	err = filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		target := filepath.Join(dest, rel)
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			dirs = append(dirs, dirMode{path: target, mode: fi.Mode().Perm()})
+			return os.Mkdir(target, 0o700)
+
+		case d.Type().IsRegular():
+			return copyRegularFile(p, target, fi.Mode().Perm())
+
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := symlinkTarget(src, p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+
+		default:
+			return fmt.Errorf("%s has an unsupported file type %s", p, d.Type())
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckSymlinks returns an error if a symlink under root escapes root.
+func CheckSymlinks(root string) error {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		_, err = symlinkTarget(root, p)
+		return err
+	})
+}
+
+// HasAbsoluteSymlinks returns true if a symlink under root has an absolute target.
+func HasAbsoluteSymlinks(root string) (bool, error) {
+	found := false
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || found || d.Type()&fs.ModeSymlink == 0 {
+			return err
+		}
+
+		link, err := os.Readlink(p)
+		if err != nil {
+			return err
+		}
+
+		found = filepath.IsAbs(link)
+		return nil
+	})
+
+	return found, err
+}
+
+// maxSymlinkHops is the maximum number of symlinks followed to resolve a path, as with the Linux kernel.
+const maxSymlinkHops = 40
+
+// symlinkTarget returns the target of a symlink under root as a path relative to the symlink's directory.
+// Absolute targets inside root are made relative; it returns an error if the target escapes root, including through
+// other symlinks under root.
+func symlinkTarget(root, p string) (string, error) {
+	link, err := os.Readlink(p)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(p)
+
+	resolved := link
+	if !filepath.IsAbs(link) {
+		resolved = filepath.Join(dir, link)
+	}
+
+	if !isInRoot(root, resolved) {
+		return "", fmt.Errorf("symlink %s escapes %s", p, root)
+	}
+
+	// The lexical check above doesn't follow the other symlinks in the target, such as a -> . and b -> a/../x.
+	if err := resolveInRoot(root, p); err != nil {
+		return "", err
+	}
+
+	return filepath.Rel(dir, resolved)
+}
+
+// resolveInRoot follows the symlinks in a path under root, one element at a time as the kernel does, and returns an
+// error if the path resolves outside root. Elements that don't exist are resolved lexically.
+func resolveInRoot(root, p string) error {
+	root = filepath.Clean(root)
+
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		return err
+	}
+
+	current := root
+	pending := splitPath(rel)
+	hops := 0
+
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			if current == root {
+				return fmt.Errorf("symlink %s escapes %s", p, root)
+			}
+			current = filepath.Dir(current)
+			continue
+		}
+
+		next := filepath.Join(current, name)
+
+		fi, err := os.Lstat(next)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		if err != nil || fi.Mode()&fs.ModeSymlink == 0 {
+			current = next
+			continue
+		}
+
+		hops++
+		if hops > maxSymlinkHops {
+			return fmt.Errorf("symlink %s has too many levels of symlinks", p)
+		}
+
+		link, err := os.Readlink(next)
+		if err != nil {
+			return err
+		}
+
+		// Absolute targets are resolved from root, and relative targets from the symlink's directory.
+		if filepath.IsAbs(link) {
+			if !isInRoot(root, link) {
+				return fmt.Errorf("symlink %s escapes %s", p, root)
+			}
+
+			current = root
+			link, err = filepath.Rel(root, link)
+			if err != nil {
+				return err
+			}
+		}
+
+		pending = append(splitPath(link), pending...)
+	}
+
+	return nil
+}
+
+// isInRoot returns true if a path is lexically root or under root.
+func isInRoot(root, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// splitPath splits a path into its elements, with either separator.
+func splitPath(p string) []string {
+	return strings.Split(filepath.ToSlash(p), "/")
+}
+
+// copyRegularFile copies a regular file to a new file with the given permissions.
+func copyRegularFile(src, dest string, perm fs.FileMode) error {
+	sf, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer sf.Close()
+
+	df, err := os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, copyErr := io.Copy(df, sf)
+	closeErr := df.Close()
+	if err := errors.Join(copyErr, closeErr); err != nil {
+		return err
+	}
+
+	return os.Chmod(dest, perm)
+}
@@ -2,6 +2,7 @@ package fileio
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/matryer/is"
@@ -55,3 +56,286 @@ func TestDirExists(t *testing.T) {
 		})
 	}
 }
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "nested")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a"), []byte("12345"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nested, "b"), []byte("123"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    int64
+		wantErr bool
+	}{
+		{
+			name:    "errors_if_the_path_does_not_exist",
+			path:    filepath.Join(dir, "non-existent-directory"),
+			wantErr: true,
+		},
+		{
+			name: "returns_the_total_size_of_nested_files",
+			path: dir,
+			want: 8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			got, err := DirSize(tt.path)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err)          // should not error
+			is.Equal(got, tt.want) // size should match
+		})
+	}
+}
+
+func TestCopyDir(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, src string)
+		check    func(t *testing.T, dest string)
+		relative bool
+		wantErr  bool
+	}{
+		{
+			name: "copies_files_and_preserves_modes",
+			setup: func(t *testing.T, src string) {
+				mustMkdir(t, filepath.Join(src, "bin"), 0o755)
+				mustWriteFile(t, filepath.Join(src, "bin", "tool"), "binary", 0o755)
+				mustWriteFile(t, filepath.Join(src, "README"), "readme", 0o600)
+				mustMkdir(t, filepath.Join(src, "readonly"), 0o555)
+			},
+			check: func(t *testing.T, dest string) {
+				is := is.New(t)
+
+				data, err := os.ReadFile(filepath.Join(dest, "bin", "tool"))
+				is.NoErr(err)                    // should read file
+				is.Equal(string(data), "binary") // content should match
+
+				fi, err := os.Stat(filepath.Join(dest, "bin", "tool"))
+				is.NoErr(err)                                  // should stat file
+				is.Equal(fi.Mode().Perm(), os.FileMode(0o755)) // should keep executable mode
+
+				fi, err = os.Stat(filepath.Join(dest, "README"))
+				is.NoErr(err)                                  // should stat file
+				is.Equal(fi.Mode().Perm(), os.FileMode(0o600)) // should keep file mode
+
+				fi, err = os.Stat(filepath.Join(dest, "readonly"))
+				is.NoErr(err)                                  // should stat dir
+				is.Equal(fi.Mode().Perm(), os.FileMode(0o555)) // should keep dir mode
+			},
+		},
+		{
+			name: "recreates_relative_symlinks",
+			setup: func(t *testing.T, src string) {
+				mustMkdir(t, filepath.Join(src, "lib"), 0o755)
+				mustMkdir(t, filepath.Join(src, "bin"), 0o755)
+				mustWriteFile(t, filepath.Join(src, "lib", "tool.js"), "script", 0o755)
+				mustSymlink(t, filepath.Join("..", "lib", "tool.js"), filepath.Join(src, "bin", "tool"))
+				mustSymlink(t, filepath.Join(src, "lib"), filepath.Join(src, "lib-abs"))
+			},
+			check: func(t *testing.T, dest string) {
+				is := is.New(t)
+
+				link, err := os.Readlink(filepath.Join(dest, "bin", "tool"))
+				is.NoErr(err)                                         // should be a symlink
+				is.Equal(link, filepath.Join("..", "lib", "tool.js")) // should keep relative target
+
+				link, err = os.Readlink(filepath.Join(dest, "lib-abs"))
+				is.NoErr(err)         // should be a symlink
+				is.Equal(link, "lib") // should make absolute target relative
+
+				data, err := os.ReadFile(filepath.Join(dest, "bin", "tool"))
+				is.NoErr(err)                    // should resolve symlink
+				is.Equal(string(data), "script") // content should match
+			},
+		},
+		{
+			name: "makes_absolute_symlinks_relative_from_a_relative_source",
+			setup: func(t *testing.T, src string) {
+				mustWriteFile(t, filepath.Join(src, "tool"), "binary", 0o755)
+				mustSymlink(t, filepath.Join(src, "tool"), filepath.Join(src, "link"))
+			},
+			check: func(t *testing.T, dest string) {
+				is := is.New(t)
+
+				link, err := os.Readlink(filepath.Join(dest, "link"))
+				is.NoErr(err)          // should be a symlink
+				is.Equal(link, "tool") // should make absolute target relative
+			},
+			relative: true,
+		},
+		{
+			name: "errors_if_a_symlink_escapes_the_source",
+			setup: func(t *testing.T, src string) {
+				mustSymlink(t, filepath.Join("..", "outside"), filepath.Join(src, "escape"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "errors_if_chained_symlinks_escape_the_source",
+			setup: func(t *testing.T, src string) {
+				mustSymlink(t, ".", filepath.Join(src, "a"))
+				mustSymlink(t, "a/../outside", filepath.Join(src, "b"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "errors_if_an_absolute_symlink_escapes_the_source",
+			setup: func(t *testing.T, src string) {
+				mustSymlink(t, os.TempDir(), filepath.Join(src, "escape"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			src := t.TempDir()
+			dest := filepath.Join(t.TempDir(), "dest")
+			tt.setup(t, src)
+
+			if tt.relative {
+				t.Chdir(filepath.Dir(src))
+				src = filepath.Base(src)
+			}
+
+			err := CopyDir(src, dest)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err) // should not error
+			tt.check(t, dest)
+		})
+	}
+}
+
+func TestCheckSymlinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		wantErr bool
+	}{
+		{
+			name:   "accepts_symlinks_inside_the_root",
+			target: filepath.Join("..", "lib"),
+		},
+		{
+			name:    "errors_if_a_symlink_escapes_the_root",
+			target:  filepath.Join("..", "..", "outside"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			root := t.TempDir()
+			mustMkdir(t, filepath.Join(root, "bin"), 0o755)
+			mustSymlink(t, tt.target, filepath.Join(root, "bin", "link"))
+
+			err := CheckSymlinks(root)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err) // should not error
+		})
+	}
+}
+
+func TestCheckSymlinks_chained(t *testing.T) {
+	tests := []struct {
+		name    string
+		via     string
+		wantErr bool
+	}{
+		{
+			name: "accepts_chained_symlinks_inside_the_root",
+			via:  "bin",
+		},
+		{
+			name:    "errors_if_chained_symlinks_escape_the_root",
+			via:     ".",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			root := t.TempDir()
+			mustMkdir(t, filepath.Join(root, "bin"), 0o755)
+			mustSymlink(t, tt.via, filepath.Join(root, "a"))
+			// The target is lexically inside the root, but a/.. is the parent of the symlink's target.
+			mustSymlink(t, "a/../outside", filepath.Join(root, "link"))
+
+			err := CheckSymlinks(root)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err) // should not error
+		})
+	}
+}
+
+func TestHasAbsoluteSymlinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		absolute bool
+		want     bool
+	}{
+		{
+			name: "returns_false_for_relative_symlinks",
+		},
+		{
+			name:     "returns_true_for_absolute_symlinks",
+			absolute: true,
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			root := t.TempDir()
+			mustMkdir(t, filepath.Join(root, "bin"), 0o755)
+
+			target := "bin"
+			if tt.absolute {
+				target = filepath.Join(root, "bin")
+			}
+			mustSymlink(t, target, filepath.Join(root, "link"))
+
+			got, err := HasAbsoluteSymlinks(root)
+
+			is.NoErr(err)          // should not error
+			is.Equal(got, tt.want) // should detect absolute symlinks
+		})
+	}
+}
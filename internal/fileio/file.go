@@ -1,6 +1,8 @@
 package fileio
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -76,3 +78,19 @@ func WriteFile(name string, value []byte) error {
 	_, err = f.Write(value)
 	return err
 }
+
+// SHA256File returns the hex encoded SHA-256 digest of a file.
+func SHA256File(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
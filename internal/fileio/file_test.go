@@ -180,3 +180,41 @@ func TestWriteFile(t *testing.T) {
 		})
 	}
 }
+
+func TestSHA256File(t *testing.T) {
+	src := createTempFile(t, "src")
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "errors_if_the_path_does_not_exist",
+			path:    "non-existent-file",
+			wantErr: true,
+		},
+		{
+			name: "returns_the_digest",
+			path: src,
+			want: "25a6634263c1b1f6fc4697a04e2b9904ea4b042a89af59dc93ec1f5d44848a26",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			got, err := SHA256File(tt.path)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err)          // should not error
+			is.Equal(got, tt.want) // digest should match
+		})
+	}
+}
@@ -20,3 +20,39 @@ func createTempFile(t *testing.T, content string) string {
 
 	return tmp.Name()
 }
+
+func mustMkdir(t *testing.T, p string, perm os.FileMode) {
+	t.Helper()
+
+	if err := os.Mkdir(p, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = os.Chmod(p, 0o755)
+	})
+
+	if err := os.Chmod(p, perm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustWriteFile(t *testing.T, p, content string, perm os.FileMode) {
+	t.Helper()
+
+	if err := os.WriteFile(p, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chmod(p, perm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustSymlink(t *testing.T, target, p string) {
+	t.Helper()
+
+	if err := os.Symlink(target, p); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -4,9 +4,17 @@ import (
 	"os"
 )
 
-const runnerDebug = "RUNNER_DEBUG"
+const (
+	runnerDebug   = "RUNNER_DEBUG"
+	githubActions = "GITHUB_ACTIONS"
+)
 
 // IsDebug returns true if the current GitHub Actions step is in debug mode.
 func IsDebug() bool {
 	return os.Getenv(runnerDebug) == "1"
 }
+
+// IsGitHubActions returns true if running in a GitHub Actions workflow.
+func IsGitHubActions() bool {
+	return os.Getenv(githubActions) == "true"
+}
@@ -35,3 +35,33 @@ func TestIsDebug(t *testing.T) {
 		})
 	}
 }
+
+func TestIsGitHubActions(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		want bool
+	}{
+		{
+			name: "returns_false_if_env_is_unset",
+			env:  "",
+			want: false,
+		},
+		{
+			name: "returns_true_if_env_is_set",
+			env:  "true",
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			t.Setenv(githubActions, tt.env)
+
+			result := IsGitHubActions()
+
+			is.Equal(result, tt.want) // should match expected
+		})
+	}
+}
@@ -23,7 +23,7 @@ const (
 // 1. RUNNER_TEMP (for GitHub Actions runner)
 // 2. TEMPDIR (for Linux)
 // 3. TMP (for Windows)
-// If none of these environment variables are set or point to a valid directory, an error is returned.
+// If none of these environment variables are set or point to a valid directory, the OS temp directory is returned.
 func GetTempDir() (string, error) {
 	for _, lookup := range []string{runnerTempLookup, linuxTempLookup, windowsTempLookup} {
 		p := os.Getenv(lookup)
@@ -41,7 +41,18 @@ func GetTempDir() (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("no temp directory found")
+	p := os.TempDir()
+
+	exists, err := fileio.DirExists(p)
+	if err != nil {
+		return "", err
+	}
+
+	if !exists {
+		return "", fmt.Errorf("no temp directory found")
+	}
+
+	return p, nil
 }
 
 // CreateTempDir creates a temporary directory and returns its path.
@@ -11,26 +11,39 @@ import (
 
 func TestGetTempDir(t *testing.T) {
 	validDir := t.TempDir()
+	osTempDir := t.TempDir()
 	nonExistentDir := filepath.Join(t.TempDir(), "test")
 
 	tests := []struct {
-		name    string
-		env     string
-		wantErr bool
+		name      string
+		env       string
+		osTempDir string
+		want      string
+		wantErr   bool
 	}{
 		{
-			name:    "errors_if_temp_directory_env_variable_is_not_defined",
-			env:     "",
-			wantErr: true,
+			name:      "falls_back_to_the_os_temp_directory_if_env_variable_is_not_defined",
+			env:       "",
+			osTempDir: osTempDir,
+			want:      osTempDir,
 		},
 		{
-			name:    "errors_if_temp_directory_does_not_exist",
-			env:     nonExistentDir,
-			wantErr: true,
+			name:      "falls_back_to_the_os_temp_directory_if_temp_directory_does_not_exist",
+			env:       nonExistentDir,
+			osTempDir: osTempDir,
+			want:      osTempDir,
 		},
 		{
-			name: "returns_the_temp_directory",
-			env:  validDir,
+			name:      "errors_if_the_os_temp_directory_does_not_exist",
+			env:       "",
+			osTempDir: nonExistentDir,
+			wantErr:   true,
+		},
+		{
+			name:      "returns_the_temp_directory",
+			env:       validDir,
+			osTempDir: osTempDir,
+			want:      validDir,
 		},
 	}
 
@@ -40,6 +53,7 @@ func TestGetTempDir(t *testing.T) {
 			t.Setenv(runnerTempLookup, tt.env)
 			t.Setenv(linuxTempLookup, "")
 			t.Setenv(windowsTempLookup, "")
+			t.Setenv("TMPDIR", tt.osTempDir)
 
 			result, err := GetTempDir()
 
@@ -48,8 +62,8 @@ func TestGetTempDir(t *testing.T) {
 				return
 			}
 
-			is.NoErr(err)            // should not error
-			is.Equal(result, tt.env) // should match
+			is.NoErr(err)             // should not error
+			is.Equal(result, tt.want) // should match
 		})
 	}
 }
@@ -63,7 +77,7 @@ func TestCreateTempDir(t *testing.T) {
 		wantErr bool
 	}{
 		{
-			name:    "errors_if_no_temp_directory_is_configured",
+			name:    "errors_if_no_temp_directory_is_found",
 			env:     "",
 			wantErr: true,
 		},
@@ -79,6 +93,7 @@ func TestCreateTempDir(t *testing.T) {
 			t.Setenv(runnerTempLookup, tt.env)
 			t.Setenv(linuxTempLookup, "")
 			t.Setenv(windowsTempLookup, "")
+			t.Setenv("TMPDIR", filepath.Join(validDir, "non-existent"))
 
 			result, err := CreateTempDir()
 
@@ -1,11 +1,17 @@
 package github
 
-import "github.com/google/go-github/v88/github"
+import (
+	"github.com/google/go-github/v88/github"
 
-// GetClient returns a GitHub client.
+	"github.com/action-stars/ghactl/internal/toolkit/httpclient"
+)
+
+// GetClient returns a GitHub client using the shared HTTP transport.
 // If a token is provided, it will be used for authentication.
 func GetClient(token *string) (*github.Client, error) {
-	options := []github.ClientOptionsFunc{}
+	options := []github.ClientOptionsFunc{
+		github.WithTransport(httpclient.Transport()),
+	}
 
 	if token != nil && *token != "" {
 		options = append(options, github.WithAuthToken(*token))
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/matryer/is"
+
+	"github.com/action-stars/ghactl/internal/toolkit/httpclient"
 )
 
 func TestGetClient(t *testing.T) {
@@ -16,6 +18,15 @@ func TestGetClient(t *testing.T) {
 		is.True(client != nil)
 	})
 
+	t.Run("uses_the_shared_transport", func(t *testing.T) {
+		is := is.New(t)
+
+		client, err := GetClient(nil)
+
+		is.NoErr(err)
+		is.Equal(client.Client().Transport, httpclient.Transport())
+	})
+
 	t.Run("returns_a_client_with_an_auth_token", func(t *testing.T) {
 		is := is.New(t)
 		token := "test-token"
@@ -3,6 +3,7 @@ package github
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
@@ -30,6 +31,13 @@ var (
 
 	// allArchIndicators is a flattened list of all known arch indicators.
 	allArchIndicators = flatten(archIndicators)
+
+	// checksumExtensions are the extensions of checksum sidecar assets.
+	checksumExtensions = []string{".sha1", ".sha256", ".sha512", ".sha256sum", ".sha512sum"}
+
+	// signatureExtensions are the extensions of signature sidecar assets, in order of preference. They're the
+	// signature formats the tool cache can verify.
+	signatureExtensions = []string{".minisig", ".sig", ".asc"}
 )
 
 // ReleaseResolution is the selected release, asset, and normalized version to install.
@@ -37,6 +45,9 @@ type ReleaseResolution struct {
 	Version   string
 	AssetName string
 	AssetURL  string
+	// SignatureURLs are the URLs of the minisign, SSH or OpenPGP signature sidecar assets of the asset, in order of
+	// preference.
+	SignatureURLs []string
 }
 
 // ResolveToolRelease selects a release and matching asset for a tool install.
@@ -92,9 +103,10 @@ func ResolveToolRelease(ctx context.Context, token, owner, repo, toolName, versi
 	}
 
 	return &ReleaseResolution{
-		Version:   normalizedVersion,
-		AssetName: assetName,
-		AssetURL:  assetURL,
+		Version:       normalizedVersion,
+		AssetName:     assetName,
+		AssetURL:      assetURL,
+		SignatureURLs: getSignatureAssetURLs(release.Assets, assetName),
 	}, nil
 }
 
@@ -224,7 +236,7 @@ func selectAsset(assets []*github.ReleaseAsset, toolName, repo, osName, arch str
 
 		assetName := strings.ToLower(asset.GetName())
 
-		// Ignore sidecar or checksum files (e.g., .sha256, .sig, .asc)
+		// Ignore sidecar or checksum files (e.g., .sha256, .sig, .asc), signatures are found by getSignatureAssetURLs
 		if isSidecarAsset(assetName) {
 			continue
 		}
@@ -294,10 +306,24 @@ candidateLoop:
 	return best.asset, nil
 }
 
+// getSignatureAssetURLs returns the URLs of the signature sidecar assets of an asset, in order of preference.
+func getSignatureAssetURLs(assets []*github.ReleaseAsset, assetName string) []string {
+	urls := []string{}
+
+	for _, ext := range signatureExtensions {
+		for _, asset := range assets {
+			if asset.GetName() == assetName+ext && asset.GetBrowserDownloadURL() != "" {
+				urls = append(urls, asset.GetBrowserDownloadURL())
+			}
+		}
+	}
+
+	return urls
+}
+
 // isSidecarAsset returns true if the asset name indicates a sidecar or checksum file.
 func isSidecarAsset(name string) bool {
-	extensions := []string{".sha1", ".sha256", ".sha512", ".sha256sum", ".sha512sum", ".sig", ".asc"}
-	for _, ext := range extensions {
+	for _, ext := range slices.Concat(checksumExtensions, signatureExtensions) {
 		if strings.HasSuffix(name, ext) {
 			return true
 		}
@@ -188,6 +188,7 @@ func Test_selectAsset(t *testing.T) {
 				{Name: new("hadolint-linux-x86_64.sha512sum"), BrowserDownloadURL: new("https://example.com/hadolint-linux-x86_64.sha512sum")},
 				{Name: new("hadolint-linux-x86_64.sig"), BrowserDownloadURL: new("https://example.com/hadolint-linux-x86_64.sig")},
 				{Name: new("hadolint-linux-x86_64.asc"), BrowserDownloadURL: new("https://example.com/hadolint-linux-x86_64.asc")},
+				{Name: new("hadolint-linux-x86_64.minisig"), BrowserDownloadURL: new("https://example.com/hadolint-linux-x86_64.minisig")},
 			},
 			toolName: "hadolint",
 			toolRepo: "hadolint",
@@ -246,3 +247,40 @@ func Test_selectAsset(t *testing.T) {
 		})
 	}
 }
+
+func Test_getSignatureAssetURLs(t *testing.T) {
+	assets := []*github.ReleaseAsset{
+		{Name: new("tool_linux_amd64.tar.gz"), BrowserDownloadURL: new("https://example.com/tool_linux_amd64.tar.gz")},
+		{Name: new("tool_linux_amd64.tar.gz.asc"), BrowserDownloadURL: new("https://example.com/tool_linux_amd64.tar.gz.asc")},
+		{Name: new("tool_linux_amd64.tar.gz.minisig"), BrowserDownloadURL: new("https://example.com/tool_linux_amd64.tar.gz.minisig")},
+		{Name: new("tool_linux_arm64.tar.gz.sig"), BrowserDownloadURL: new("https://example.com/tool_linux_arm64.tar.gz.sig")},
+		{Name: new("checksums.txt.sig"), BrowserDownloadURL: new("https://example.com/checksums.txt.sig")},
+	}
+
+	tests := []struct {
+		name      string
+		assetName string
+		want      []string
+	}{
+		{
+			name:      "returns_signatures_in_order_of_preference",
+			assetName: "tool_linux_amd64.tar.gz",
+			want:      []string{"https://example.com/tool_linux_amd64.tar.gz.minisig", "https://example.com/tool_linux_amd64.tar.gz.asc"},
+		},
+		{
+			name:      "returns_no_signatures_for_unsigned_asset",
+			assetName: "tool_darwin_arm64.tar.gz",
+			want:      []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			got := getSignatureAssetURLs(assets, tt.assetName)
+
+			is.Equal(got, tt.want) // should return the signature URLs
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+)
+
+var (
+	// transport is the HTTP transport shared by the download and GitHub API clients.
+	transport http.RoundTripper = newDefaultTransport()
+
+	// transportMu guards transport.
+	transportMu sync.RWMutex
+)
+
+// TLSOptions are the certificates used for TLS connections.
+type TLSOptions struct {
+	// CACert is the path to a PEM bundle of CA certificates trusted in addition to the system roots.
+	CACert string
+	// ClientCert is the path to a PEM client certificate presented to servers that request one.
+	ClientCert string
+	// ClientKey is the path to the PEM private key of the client certificate. Defaults to ClientCert, for a PEM file
+	// with both the certificate and the key.
+	ClientKey string
+}
+
+// Transport returns the shared HTTP transport.
+func Transport() http.RoundTripper {
+	transportMu.RLock()
+	defer transportMu.RUnlock()
+
+	return transport
+}
+
+// Configure replaces the shared HTTP transport with one that uses the TLS options.
+// Empty options restore the default transport.
+func Configure(options TLSOptions) error {
+	t, err := NewTransport(options)
+	if err != nil {
+		return err
+	}
+
+	transportMu.Lock()
+	defer transportMu.Unlock()
+
+	transport = t
+	return nil
+}
+
+// NewTransport returns an HTTP transport that trusts the system roots and the CA bundle, and presents the client
+// certificate, in the TLS options.
+func NewTransport(options TLSOptions) (*http.Transport, error) {
+	t := newDefaultTransport()
+
+	if options.CACert == "" && options.ClientCert == "" && options.ClientKey == "" {
+		return t, nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if options.CACert != "" {
+		pool, err := loadCertPool(options.CACert)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+
+	if options.ClientCert == "" && options.ClientKey != "" {
+		return nil, fmt.Errorf("client key is defined without a client certificate")
+	}
+
+	if options.ClientCert != "" {
+		key := options.ClientKey
+		if key == "" {
+			key = options.ClientCert
+		}
+
+		cert, err := tls.LoadX509KeyPair(options.ClientCert, key)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	t.TLSClientConfig = config
+	return t, nil
+}
+
+// loadCertPool returns the system cert pool with the certificates in a PEM bundle added.
+func loadCertPool(p string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA bundle %s has no PEM certificates", p)
+	}
+
+	return pool, nil
+}
+
+// newDefaultTransport returns a transport with the defaults of http.DefaultTransport, including the proxy from the
+// environment.
+func newDefaultTransport() *http.Transport {
+	return http.DefaultTransport.(*http.Transport).Clone()
+}
@@ -0,0 +1,207 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestNewTransport(t *testing.T) {
+	t.Run("trusts_the_ca_bundle", func(t *testing.T) {
+		is := is.New(t)
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "ok")
+		}))
+		defer ts.Close()
+
+		caCert := writeCertificate(t, ts.Certificate())
+
+		tr, err := NewTransport(TLSOptions{CACert: caCert})
+		is.NoErr(err) // should not error
+
+		resp, err := (&http.Client{Transport: tr}).Get(ts.URL)
+		is.NoErr(err) // should trust the server certificate
+		resp.Body.Close()
+	})
+
+	t.Run("does_not_trust_unknown_certificates", func(t *testing.T) {
+		is := is.New(t)
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "ok")
+		}))
+		defer ts.Close()
+
+		tr, err := NewTransport(TLSOptions{})
+		is.NoErr(err) // should not error
+
+		_, err = (&http.Client{Transport: tr}).Get(ts.URL)
+		is.True(err != nil) // should not trust the server certificate
+	})
+
+	t.Run("presents_the_client_certificate", func(t *testing.T) {
+		is := is.New(t)
+		clientCert, clientKey, leaf := createClientCertificate(t)
+
+		pool := x509.NewCertPool()
+		pool.AddCert(leaf)
+
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+		}))
+		ts.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+		ts.StartTLS()
+		defer ts.Close()
+
+		caCert := writeCertificate(t, ts.Certificate())
+
+		tr, err := NewTransport(TLSOptions{CACert: caCert, ClientCert: clientCert, ClientKey: clientKey})
+		is.NoErr(err) // should not error
+
+		resp, err := (&http.Client{Transport: tr}).Get(ts.URL)
+		is.NoErr(err) // should complete mutual TLS
+		defer resp.Body.Close()
+		is.Equal(resp.StatusCode, http.StatusOK) // should be accepted
+	})
+
+	t.Run("errors_if_ca_bundle_does_not_exist", func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := NewTransport(TLSOptions{CACert: filepath.Join(t.TempDir(), "missing.pem")})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("errors_if_ca_bundle_has_no_certificates", func(t *testing.T) {
+		is := is.New(t)
+		p := filepath.Join(t.TempDir(), "ca.pem")
+		if err := os.WriteFile(p, []byte("not a certificate"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := NewTransport(TLSOptions{CACert: p})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("errors_if_client_key_has_no_certificate", func(t *testing.T) {
+		is := is.New(t)
+		_, clientKey, _ := createClientCertificate(t)
+
+		_, err := NewTransport(TLSOptions{ClientKey: clientKey})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("errors_if_client_certificate_has_no_key", func(t *testing.T) {
+		is := is.New(t)
+		clientCert, _, _ := createClientCertificate(t)
+
+		_, err := NewTransport(TLSOptions{ClientCert: clientCert})
+
+		is.True(err != nil) // should error
+	})
+}
+
+func TestConfigure(t *testing.T) {
+	t.Run("replaces_the_shared_transport", func(t *testing.T) {
+		is := is.New(t)
+		t.Cleanup(func() { _ = Configure(TLSOptions{}) })
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "ok")
+		}))
+		defer ts.Close()
+
+		err := Configure(TLSOptions{CACert: writeCertificate(t, ts.Certificate())})
+		is.NoErr(err) // should not error
+
+		resp, err := (&http.Client{Transport: Transport()}).Get(ts.URL)
+		is.NoErr(err) // should use the CA bundle
+		resp.Body.Close()
+	})
+
+	t.Run("keeps_the_shared_transport_on_error", func(t *testing.T) {
+		is := is.New(t)
+		before := Transport()
+
+		err := Configure(TLSOptions{CACert: filepath.Join(t.TempDir(), "missing.pem")})
+
+		is.True(err != nil)           // should error
+		is.Equal(Transport(), before) // should keep the transport
+	})
+}
+
+// writeCertificate writes a certificate to a PEM file and returns its path.
+func writeCertificate(t *testing.T, cert *x509.Certificate) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(p, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+// createClientCertificate creates a self-signed client certificate and returns the paths to its PEM certificate and key.
+func createClientCertificate(t *testing.T) (string, string, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ghactl-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "client.pem")
+	keyPath := filepath.Join(dir, "client-key.pem")
+
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certPath, keyPath, leaf
+}
@@ -0,0 +1,348 @@
+package toolcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/action-stars/ghactl/internal/archive"
+	"github.com/action-stars/ghactl/internal/fileio"
+	"github.com/action-stars/ghactl/internal/toolkit/core"
+)
+
+// BundleSelector selects the tool cache entries to export to a bundle.
+type BundleSelector struct {
+	Tool string
+	// Arch limits the selection to a single architecture. If empty, all architectures are selected.
+	Arch        string
+	VersionSpec string
+}
+
+// ExportBundle packs the complete entries in the GitHub Actions runner tool cache matching the selectors,
+// along with their markers and sidecars, into a tar.gz bundle using the tool cache layout.
+// It returns the exported entries.
+func ExportBundle(bundlePath string, selectors []BundleSelector) ([]CacheEntry, error) {
+	if len(selectors) == 0 {
+		return nil, fmt.Errorf("no tools are selected")
+	}
+
+	exported := []CacheEntry{}
+	seen := map[string]bool{}
+
+	for _, s := range selectors {
+		entries, err := selectBundleEntries(s)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entries {
+			if !seen[e.Path] {
+				seen[e.Path] = true
+				exported = append(exported, e)
+			}
+		}
+	}
+
+	stagingPath, err := core.CreateTempDir()
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stagingPath)
+
+	for _, e := range exported {
+		if err := copyBundleEntry(e, filepath.Join(stagingPath, e.Tool, e.Version, e.Arch)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Tar(stagingPath, bundlePath, true); err != nil {
+		return nil, errors.Join(err, os.Remove(bundlePath))
+	}
+
+	return exported, nil
+}
+
+// ImportBundle validates a bundle written by ExportBundle and publishes each of its entries to the GitHub Actions runner tool cache
+// with CacheDir, holding the entry's lock while it is published.
+// Nothing is published unless every entry in the bundle is valid.
+// It returns the paths of the imported entries.
+func ImportBundle(ctx context.Context, bundlePath string) ([]string, error) {
+	stagingPath, err := createExtractDir()
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stagingPath)
+
+	if err := archive.UnTar(bundlePath, stagingPath, true); err != nil {
+		return nil, fmt.Errorf("extracting bundle: %w", err)
+	}
+
+	entries, err := readBundleEntries(stagingPath)
+	if err != nil {
+		return nil, err
+	}
+
+	source, _ := filepath.Abs(bundlePath)
+
+	imported := make([]string, 0, len(entries))
+	for _, e := range entries {
+		p, err := importBundleEntry(ctx, e, source)
+		if err != nil {
+			return nil, err
+		}
+
+		imported = append(imported, p)
+	}
+
+	return imported, nil
+}
+
+// bundleEntry is a validated tool cache entry extracted from a bundle.
+type bundleEntry struct {
+	CacheEntry
+	Metadata *Metadata
+}
+
+// selectBundleEntries returns the complete tool cache entries matching a selector.
+// It returns an error if no entry matches.
+func selectBundleEntries(s BundleSelector) ([]CacheEntry, error) {
+	if s.Tool == "" {
+		return nil, fmt.Errorf("tool is not defined")
+	}
+
+	if s.VersionSpec == "" {
+		return nil, fmt.Errorf("versionSpec is not defined")
+	}
+
+	c, err := semver.NewConstraint(s.VersionSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := ListCacheEntries(s.Tool)
+	if err != nil {
+		return nil, err
+	}
+
+	archSegment := PlatformArch("", s.Arch)
+
+	selected := []CacheEntry{}
+	for _, e := range entries {
+		if !e.Complete || (s.Arch != "" && e.Arch != archSegment) {
+			continue
+		}
+
+		v, err := ParseVersion(e.Version, false)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+
+		selected = append(selected, e)
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("tool %s %s is not cached", s.Tool, s.VersionSpec)
+	}
+
+	return selected, nil
+}
+
+// copyBundleEntry copies a tool cache entry and its marker and sidecars to a path in a bundle staging directory.
+func copyBundleEntry(e CacheEntry, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+
+	if err := fileio.CopyDir(e.Path, dest); err != nil {
+		return fmt.Errorf("copying %s: %w", e.Path, err)
+	}
+
+	for _, sidecar := range []func(string) string{getMarkerPath, getMetadataPath, getHashTreePath} {
+		exists, err := fileio.FileExists(sidecar(e.Path))
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			continue
+		}
+
+		if err := fileio.CopyFile(sidecar(e.Path), sidecar(dest), false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readBundleEntries returns the entries of an extracted bundle, validating that it only contains
+// complete tool cache entries whose metadata and hash tree match their contents.
+func readBundleEntries(root string) ([]bundleEntry, error) {
+	entries := []bundleEntry{}
+
+	tools, err := readBundleDirNames(root)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tool := range tools {
+		toolPath := filepath.Join(root, tool)
+
+		versions, err := readBundleDirNames(toolPath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, version := range versions {
+			if _, err := ParseVersion(version, false); err != nil {
+				return nil, fmt.Errorf("bundle entry %s/%s has an invalid version: %w", tool, version, err)
+			}
+
+			versionPath := filepath.Join(toolPath, version)
+
+			archs, err := readBundleArchNames(versionPath)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, arch := range archs {
+				osName, _ := splitPlatformArch(arch)
+
+				e, err := readBundleEntry(CacheEntry{
+					Tool:     tool,
+					Version:  version,
+					Arch:     arch,
+					OS:       osName,
+					Path:     filepath.Join(versionPath, arch),
+					Complete: true,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				entries = append(entries, e)
+			}
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("bundle has no tool cache entries")
+	}
+
+	return entries, nil
+}
+
+// readBundleDirNames returns the names of the directories in a bundle directory,
+// returning an error if it contains anything else.
+func readBundleDirNames(p string) ([]string, error) {
+	items, err := os.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		if !item.IsDir() || strings.HasPrefix(item.Name(), ".") {
+			return nil, fmt.Errorf("bundle has an unexpected entry: %s", filepath.Join(p, item.Name()))
+		}
+
+		names = append(names, item.Name())
+	}
+
+	return names, nil
+}
+
+// readBundleArchNames returns the architectures in a bundle version directory,
+// returning an error if it contains anything other than architecture directories and their marker and sidecars.
+func readBundleArchNames(versionPath string) ([]string, error) {
+	items, err := os.ReadDir(versionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	archs := []string{}
+	files := map[string]bool{}
+
+	for _, item := range items {
+		if item.IsDir() && !strings.HasPrefix(item.Name(), ".") {
+			archs = append(archs, item.Name())
+			continue
+		}
+
+		if !item.Type().IsRegular() {
+			return nil, fmt.Errorf("bundle has an unexpected entry: %s", filepath.Join(versionPath, item.Name()))
+		}
+
+		files[item.Name()] = true
+	}
+
+	for _, arch := range archs {
+		toolPath := filepath.Join(versionPath, arch)
+		if !files[filepath.Base(getMarkerPath(toolPath))] {
+			return nil, fmt.Errorf("bundle entry %s is not complete", toolPath)
+		}
+
+		for _, sidecar := range []func(string) string{getMarkerPath, getMetadataPath, getHashTreePath} {
+			delete(files, filepath.Base(sidecar(toolPath)))
+		}
+	}
+
+	for name := range files {
+		return nil, fmt.Errorf("bundle has an unexpected entry: %s", filepath.Join(versionPath, name))
+	}
+
+	return archs, nil
+}
+
+// readBundleEntry validates an extracted bundle entry against its metadata and hash tree.
+func readBundleEntry(e CacheEntry) (bundleEntry, error) {
+	name := fmt.Sprintf("%s/%s/%s", e.Tool, e.Version, e.Arch)
+
+	metadata, err := readMetadata(e.Path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return bundleEntry{}, fmt.Errorf("bundle entry %s: %w", name, err)
+	}
+
+	if metadata != nil {
+		_, nodeArch := splitPlatformArch(e.Arch)
+		if metadata.Tool != e.Tool || metadata.Version != e.Version || metadata.Arch != nodeArch || (e.OS != "" && metadata.OS != e.OS) {
+			return bundleEntry{}, fmt.Errorf("bundle entry %s does not match its metadata", name)
+		}
+	}
+
+	result, err := verifyCacheEntry(e)
+	if err != nil {
+		return bundleEntry{}, err
+	}
+
+	if !result.Unverified && !result.OK() {
+		return bundleEntry{}, fmt.Errorf("bundle entry %s failed verification", name)
+	}
+
+	return bundleEntry{CacheEntry: e, Metadata: metadata}, nil
+}
+
+// importBundleEntry publishes an extracted bundle entry to the tool cache while holding its lock.
+// An entry with metadata is published for the OS it was cached for, so entries exported from a host with another OS
+// don't replace the host tools. If the entry has no metadata, the bundle is recorded as its source.
+func importBundleEntry(ctx context.Context, e bundleEntry, source string) (string, error) {
+	metadata := Metadata{Source: MetadataSource{Path: source}}
+	if e.Metadata != nil {
+		metadata = *e.Metadata
+	}
+
+	lock, err := LockTool(ctx, e.Tool, e.Version, PlatformArch(metadata.OS, e.Arch))
+	if err != nil {
+		return "", err
+	}
+	defer lock.Close()
+
+	// The entry was extracted from the bundle into a temporary directory, so it can be moved.
+	return CacheDir(e.Path, e.Tool, e.Version, e.Arch, metadata, CacheDirOptions{Move: true})
+}
@@ -0,0 +1,269 @@
+package toolcache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/action-stars/ghactl/internal/fileio"
+)
+
+func TestExportBundle(t *testing.T) {
+	tests := []struct {
+		name      string
+		selectors []BundleSelector
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:    "errors_if_no_tools_are_selected",
+			wantErr: true,
+		},
+		{
+			name:      "errors_if_version_spec_is_not_defined",
+			selectors: []BundleSelector{{Tool: "tool"}},
+			wantErr:   true,
+		},
+		{
+			name:      "errors_if_no_entry_matches",
+			selectors: []BundleSelector{{Tool: "tool", VersionSpec: "^2.0.0"}},
+			wantErr:   true,
+		},
+		{
+			name:      "errors_if_only_incomplete_entries_match",
+			selectors: []BundleSelector{{Tool: "tool", VersionSpec: "1.2.0"}},
+			wantErr:   true,
+		},
+		{
+			name:      "exports_matching_entries_for_all_archs",
+			selectors: []BundleSelector{{Tool: "tool", VersionSpec: "1.0.0"}},
+			want:      []string{"tool/1.0.0/arm64", "tool/1.0.0/x64"},
+		},
+		{
+			name:      "exports_matching_entries_for_arch",
+			selectors: []BundleSelector{{Tool: "tool", Arch: "amd64", VersionSpec: "~1.0.0"}},
+			want:      []string{"tool/1.0.0/x64"},
+		},
+		{
+			name: "exports_several_tools",
+			selectors: []BundleSelector{
+				{Tool: "tool", Arch: "amd64", VersionSpec: "1.0.0"},
+				{Tool: "other-tool", VersionSpec: "*"},
+				{Tool: "tool", Arch: "amd64", VersionSpec: "*"},
+			},
+			want: []string{"tool/1.0.0/x64", "other-tool/2.0.0/x64", "tool/1.1.0/x64"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			tc := t.TempDir()
+			t.Setenv(runnerToolCacheLookup, tc)
+			t.Setenv("RUNNER_TEMP", t.TempDir())
+
+			mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "x64", true)
+			mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "arm64", true)
+			mustCreateTestCacheEntry(t, tc, "tool", "1.1.0", "x64", true)
+			mustCreateTestCacheEntry(t, tc, "tool", "1.2.0", "x64", false)
+			mustCreateTestCacheEntry(t, tc, "other-tool", "2.0.0", "x64", true)
+
+			bundlePath := filepath.Join(t.TempDir(), "bundle.tar.gz")
+
+			entries, err := ExportBundle(bundlePath, tt.selectors)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err) // should not error
+
+			got := make([]string, 0, len(entries))
+			for _, e := range entries {
+				got = append(got, e.Tool+"/"+e.Version+"/"+e.Arch)
+			}
+			is.Equal(got, tt.want) // should export the matching entries
+
+			names := readTestBundleNames(t, bundlePath)
+			for _, w := range tt.want {
+				is.True(names[w+"/tool"] || names[w+"/other-tool"]) // should bundle the tool files
+				is.True(names[w+".complete"])                       // should bundle the marker
+			}
+		})
+	}
+}
+
+func TestImportBundle(t *testing.T) {
+	t.Run("restores_exported_entries", func(t *testing.T) {
+		is := is.New(t)
+		src := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, src)
+		t.Setenv("RUNNER_TEMP", t.TempDir())
+
+		source := t.TempDir()
+		mustCreateTestFile(t, filepath.Join(source, "tool"), "binary")
+		_, err := CacheDir(source, "tool", "1.0.0", "amd64", Metadata{Source: MetadataSource{URL: "https://example.com/tool"}}, CacheDirOptions{})
+		is.NoErr(err) // should cache tool
+		mustCreateTestCacheEntry(t, src, "other-tool", "2.0.0", "arm64", true)
+
+		bundlePath := filepath.Join(t.TempDir(), "bundle.tar.gz")
+		_, err = ExportBundle(bundlePath, []BundleSelector{{Tool: "tool", VersionSpec: "*"}, {Tool: "other-tool", VersionSpec: "*"}})
+		is.NoErr(err) // should export bundle
+
+		dest := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, dest)
+
+		paths, err := ImportBundle(context.Background(), bundlePath)
+
+		is.NoErr(err) // should not error
+		is.Equal(paths, []string{
+			filepath.Join(dest, "other-tool", "2.0.0", "arm64"),
+			filepath.Join(dest, "tool", "1.0.0", "x64"),
+		}) // should import all entries
+
+		p, err := FindTool("tool", "amd64", "1.0.0", FindOptions{})
+		is.NoErr(err)                                            // should not error
+		is.Equal(p, filepath.Join(dest, "tool", "1.0.0", "x64")) // should find the imported tool
+
+		metadata, err := GetMetadata("tool", "amd64", "1.0.0", FindOptions{})
+		is.NoErr(err)                                             // should read metadata
+		is.Equal(metadata.Source.URL, "https://example.com/tool") // should keep the original source
+
+		metadata, err = GetMetadata("other-tool", "arm64", "2.0.0", FindOptions{})
+		is.NoErr(err)                              // should read metadata
+		is.Equal(metadata.Source.Path, bundlePath) // should record the bundle as the source
+
+		results, err := VerifyToolCache("", false)
+		is.NoErr(err)             // should verify
+		is.Equal(len(results), 2) // should verify both entries
+		for _, r := range results {
+			is.True(r.OK()) // imported entries should verify
+		}
+	})
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "errors_if_bundle_is_empty",
+			files:   map[string]string{},
+			wantErr: true,
+		},
+		{
+			name: "errors_if_entry_is_not_complete",
+			files: map[string]string{
+				"tool/1.0.0/x64/tool": "binary",
+			},
+			wantErr: true,
+		},
+		{
+			name: "errors_if_version_is_invalid",
+			files: map[string]string{
+				"tool/latest/x64/tool":     "binary",
+				"tool/latest/x64.complete": "",
+			},
+			wantErr: true,
+		},
+		{
+			name: "errors_if_bundle_has_unexpected_files",
+			files: map[string]string{
+				"tool/1.0.0/x64/tool":     "binary",
+				"tool/1.0.0/x64.complete": "",
+				"tool/1.0.0/readme.txt":   "unexpected",
+			},
+			wantErr: true,
+		},
+		{
+			name: "errors_if_metadata_does_not_match",
+			files: map[string]string{
+				"tool/1.0.0/x64/tool":          "binary",
+				"tool/1.0.0/x64.complete":      "",
+				"tool/1.0.0/x64.metadata.json": `{"tool":"tool","version":"2.0.0","arch":"x64"}`,
+			},
+			wantErr: true,
+		},
+		{
+			name: "errors_if_hash_tree_does_not_match",
+			files: map[string]string{
+				"tool/1.0.0/x64/tool":      "tampered",
+				"tool/1.0.0/x64.complete":  "",
+				"tool/1.0.0/x64.sha256sum": "9a3a45d01531a20e89ac6ae10b0b0beb0492acd7216a368aa062d1a5fecaf9cd  tool\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "imports_entry_without_sidecars",
+			files: map[string]string{
+				"tool/1.0.0/x64/tool":     "binary",
+				"tool/1.0.0/x64.complete": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			tc := t.TempDir()
+			t.Setenv(runnerToolCacheLookup, tc)
+			t.Setenv("RUNNER_TEMP", t.TempDir())
+
+			bundlePath := writeTestBundle(t, tt.files)
+
+			paths, err := ImportBundle(context.Background(), bundlePath)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+
+				exists, _ := fileio.DirExists(filepath.Join(tc, "tool"))
+				is.True(!exists) // should not publish anything
+				return
+			}
+
+			is.NoErr(err)                                                        // should not error
+			is.Equal(paths, []string{filepath.Join(tc, "tool", "1.0.0", "x64")}) // should import the entry
+		})
+	}
+}
+
+func TestImportBundle_os(t *testing.T) {
+	t.Run("imports_entry_for_the_os_in_its_metadata", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, tc)
+		t.Setenv("RUNNER_TEMP", t.TempDir())
+
+		bundlePath := writeTestBundle(t, map[string]string{
+			"tool/1.0.0/x64/tool":          "binary",
+			"tool/1.0.0/x64.complete":      "",
+			"tool/1.0.0/x64.metadata.json": `{"tool":"tool","version":"1.0.0","os":"` + otherOS() + `","arch":"x64"}`,
+		})
+
+		paths, err := ImportBundle(context.Background(), bundlePath)
+
+		is.NoErr(err)                                                                   // should not error
+		is.Equal(paths, []string{filepath.Join(tc, "tool", "1.0.0", otherOS()+"-x64")}) // should import the entry for its OS
+	})
+
+	t.Run("errors_if_metadata_os_does_not_match", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, tc)
+		t.Setenv("RUNNER_TEMP", t.TempDir())
+
+		arch := otherOS() + "-x64"
+		bundlePath := writeTestBundle(t, map[string]string{
+			"tool/1.0.0/" + arch + "/tool":          "binary",
+			"tool/1.0.0/" + arch + ".complete":      "",
+			"tool/1.0.0/" + arch + ".metadata.json": `{"tool":"tool","version":"1.0.0","os":"plan9","arch":"x64"}`,
+		})
+
+		_, err := ImportBundle(context.Background(), bundlePath)
+
+		is.True(err != nil) // should error
+	})
+}
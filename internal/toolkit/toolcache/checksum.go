@@ -0,0 +1,145 @@
+package toolcache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	// SHA256 is the SHA-256 checksum algorithm.
+	SHA256 = "sha256"
+	// SHA512 is the SHA-512 checksum algorithm.
+	SHA512 = "sha512"
+
+	// maxChecksumFileSize is the maximum size of a checksum file.
+	maxChecksumFileSize = 1 << 20
+)
+
+// Checksum is the expected digest of a file.
+type Checksum struct {
+	// Algorithm is SHA256 or SHA512.
+	Algorithm string
+	// Digest is the hex encoded digest.
+	Digest string
+}
+
+// NewChecksum returns a checksum for an algorithm and a hex encoded digest, validating the digest length.
+func NewChecksum(algorithm, digest string) (Checksum, error) {
+	size, err := getDigestSize(algorithm)
+	if err != nil {
+		return Checksum{}, err
+	}
+
+	digest = strings.ToLower(strings.TrimSpace(digest))
+	if b, err := hex.DecodeString(digest); err != nil || len(b) != size {
+		return Checksum{}, fmt.Errorf("%s digest %q is not valid", algorithm, digest)
+	}
+
+	return Checksum{Algorithm: algorithm, Digest: digest}, nil
+}
+
+// ParseChecksum returns the checksum for a file name from the contents of a checksum file.
+// Lines in sha256sum and sha512sum format, as written by GoReleaser to checksums.txt, and in BSD format are supported,
+// and the algorithm is detected from the digest length. A file with a single digest and no file name is also supported.
+func ParseChecksum(data []byte, name string) (Checksum, error) {
+	lines := []string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Checksum{}, err
+	}
+
+	if len(lines) == 1 && !strings.ContainsAny(lines[0], " \t") {
+		return newChecksumFromDigest(lines[0])
+	}
+
+	for _, line := range lines {
+		digest, fileName, ok := parseChecksumLine(line)
+		if !ok || path.Base(fileName) != name {
+			continue
+		}
+
+		return newChecksumFromDigest(digest)
+	}
+
+	return Checksum{}, fmt.Errorf("no checksum found for %s", name)
+}
+
+// ReadChecksumFile returns the checksum for a file name from a checksum file.
+func ReadChecksumFile(p, name string) (Checksum, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return Checksum{}, err
+	}
+
+	return ParseChecksum(data, name)
+}
+
+// DownloadChecksum downloads a checksum file from a URL and returns the checksum for a file name from it.
+// The file is requested as described for downloadSmallFile.
+func DownloadChecksum(ctx context.Context, logger any, u url.URL, name string, options DownloadOptions) (Checksum, error) {
+	data, err := downloadSmallFile(ctx, logger, u, options, maxChecksumFileSize)
+	if err != nil {
+		return Checksum{}, fmt.Errorf("downloading checksum file: %w", err)
+	}
+
+	return ParseChecksum(data, name)
+}
+
+// parseChecksumLine parses a checksum line in sha256sum or BSD format into its digest and file name.
+func parseChecksumLine(line string) (string, string, bool) {
+	// BSD format: SHA256 (name) = digest
+	if i := strings.Index(line, " ("); i > 0 {
+		if j := strings.LastIndex(line, ") = "); j > i {
+			return line[j+len(") = "):], line[i+len(" (") : j], true
+		}
+	}
+
+	digest, fileName, ok := strings.Cut(line, " ")
+	if !ok {
+		return "", "", false
+	}
+
+	// The file name is prefixed with * in binary mode, and with a space in text mode.
+	fileName = strings.TrimPrefix(strings.TrimPrefix(fileName, " "), "*")
+	return digest, fileName, true
+}
+
+// newChecksumFromDigest returns the checksum for a hex encoded digest, detecting the algorithm from its length.
+func newChecksumFromDigest(digest string) (Checksum, error) {
+	switch len(digest) {
+	case sha256.Size * 2:
+		return NewChecksum(SHA256, digest)
+	case sha512.Size * 2:
+		return NewChecksum(SHA512, digest)
+	default:
+		return Checksum{}, fmt.Errorf("digest %q is not a SHA-256 or SHA-512 digest", digest)
+	}
+}
+
+// getDigestSize returns the digest size in bytes of a checksum algorithm.
+func getDigestSize(algorithm string) (int, error) {
+	switch algorithm {
+	case SHA256:
+		return sha256.Size, nil
+	case SHA512:
+		return sha512.Size, nil
+	default:
+		return 0, fmt.Errorf("checksum algorithm %s is not supported", algorithm)
+	}
+}
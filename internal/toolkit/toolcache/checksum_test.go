@@ -0,0 +1,197 @@
+package toolcache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+const (
+	testSHA256 = "e0ac3601005dfa1864f5392aabaf7d898b1b5bab854f1acb4491bcd806b76b0c"
+	testSHA512 = "2fb1877301854ac92dd518018f97407a0a88bb696bfef0a51e9efbd39917353500009e15bd72c3f0e4bf690115870bfab926565d5ad97269d922dbbb41261221"
+)
+
+func TestNewChecksum(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		digest    string
+		want      Checksum
+		wantErr   bool
+	}{
+		{
+			name:      "returns_sha256_checksum",
+			algorithm: SHA256,
+			digest:    testSHA256,
+			want:      Checksum{Algorithm: SHA256, Digest: testSHA256},
+		},
+		{
+			name:      "normalizes_digest",
+			algorithm: SHA256,
+			digest:    " " + strings.ToUpper(testSHA256) + "\n",
+			want:      Checksum{Algorithm: SHA256, Digest: testSHA256},
+		},
+		{
+			name:      "errors_if_algorithm_is_not_supported",
+			algorithm: "md5",
+			digest:    testSHA256,
+			wantErr:   true,
+		},
+		{
+			name:      "errors_if_digest_is_not_hex",
+			algorithm: SHA256,
+			digest:    strings.Repeat("z", 64),
+			wantErr:   true,
+		},
+		{
+			name:      "errors_if_digest_length_does_not_match",
+			algorithm: SHA512,
+			digest:    testSHA256,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			got, err := NewChecksum(tt.algorithm, tt.digest)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err)          // should not error
+			is.Equal(got, tt.want) // should match expected
+		})
+	}
+}
+
+func TestParseChecksum(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Checksum
+		wantErr bool
+	}{
+		{
+			name: "parses_sha256sum_line",
+			data: testSHA256 + "  tool.tar.gz\n",
+			want: Checksum{Algorithm: SHA256, Digest: testSHA256},
+		},
+		{
+			name: "parses_binary_mode_line",
+			data: testSHA256 + " *tool.tar.gz\n",
+			want: Checksum{Algorithm: SHA256, Digest: testSHA256},
+		},
+		{
+			name: "picks_line_matching_the_name",
+			data: strings.Repeat("0", 64) + "  tool.zip\n" + testSHA256 + "  tool.tar.gz\n" + strings.Repeat("1", 64) + "  tool.tar.gz.sbom\n",
+			want: Checksum{Algorithm: SHA256, Digest: testSHA256},
+		},
+		{
+			name: "matches_names_with_a_directory",
+			data: testSHA256 + "  ./dist/tool.tar.gz\n",
+			want: Checksum{Algorithm: SHA256, Digest: testSHA256},
+		},
+		{
+			name: "parses_sha512sum_line",
+			data: testSHA512 + "  tool.tar.gz\n",
+			want: Checksum{Algorithm: SHA512, Digest: testSHA512},
+		},
+		{
+			name: "parses_bsd_line",
+			data: "SHA256 (tool.tar.gz) = " + testSHA256 + "\n",
+			want: Checksum{Algorithm: SHA256, Digest: testSHA256},
+		},
+		{
+			name: "parses_single_digest",
+			data: testSHA256 + "\n",
+			want: Checksum{Algorithm: SHA256, Digest: testSHA256},
+		},
+		{
+			name:    "errors_if_no_line_matches",
+			data:    testSHA256 + "  tool.zip\n",
+			wantErr: true,
+		},
+		{
+			name:    "errors_if_digest_is_invalid",
+			data:    "abc  tool.tar.gz\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			got, err := ParseChecksum([]byte(tt.data), "tool.tar.gz")
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err)          // should not error
+			is.Equal(got, tt.want) // should match expected
+		})
+	}
+}
+
+func TestReadChecksumFile(t *testing.T) {
+	t.Run("errors_if_file_does_not_exist", func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := ReadChecksumFile(filepath.Join(t.TempDir(), "checksums.txt"), "tool.tar.gz")
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("reads_checksum_from_file", func(t *testing.T) {
+		is := is.New(t)
+		p := filepath.Join(t.TempDir(), "checksums.txt")
+		mustCreateTestFile(t, p, testSHA256+"  tool.tar.gz\n")
+
+		got, err := ReadChecksumFile(p, "tool.tar.gz")
+
+		is.NoErr(err)                                                  // should not error
+		is.Equal(got, Checksum{Algorithm: SHA256, Digest: testSHA256}) // should match expected
+	})
+}
+
+func TestDownloadChecksum(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, testSHA256+"  tool.tar.gz\n")
+	}))
+	defer ts.Close()
+
+	t.Run("downloads_checksum", func(t *testing.T) {
+		is := is.New(t)
+		u, _ := url.Parse(ts.URL + "/checksums.txt")
+
+		got, err := DownloadChecksum(t.Context(), nil, *u, "tool.tar.gz", DownloadOptions{})
+
+		is.NoErr(err)                                                  // should not error
+		is.Equal(got, Checksum{Algorithm: SHA256, Digest: testSHA256}) // should match expected
+	})
+
+	t.Run("errors_on_non-2xx_status", func(t *testing.T) {
+		is := is.New(t)
+		u, _ := url.Parse(ts.URL + "/missing")
+
+		_, err := DownloadChecksum(t.Context(), nil, *u, "tool.tar.gz", DownloadOptions{})
+
+		is.True(err != nil) // should error
+	})
+}
@@ -2,50 +2,694 @@ package toolcache
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
 
 	"github.com/action-stars/ghactl/internal/toolkit/core"
+	"github.com/action-stars/ghactl/internal/toolkit/httpclient"
 )
 
-// DownloadTool downloads a tool from a URL and saves it to a temporary directory.
-func DownloadTool(ctx context.Context, logger any, url url.URL) (string, error) {
-	d, err := core.GetTempDir()
+const (
+	// maxRedirects is the maximum number of redirects followed when downloading.
+	maxRedirects = 10
+	// maxResumeAttempts is the maximum number of times an interrupted download is resumed.
+	maxResumeAttempts = 5
+
+	// defaultDownloadName is the file name of a download if the server doesn't provide one.
+	defaultDownloadName = "download"
+	// genericContentType is the Content-Type of a download with no specific MIME type.
+	genericContentType = "application/octet-stream"
+
+	// DefaultRetries is the default maximum number of times a failed download request is retried.
+	DefaultRetries = 4
+	// DefaultRetryWaitMin is the default minimum wait before retrying a download request.
+	DefaultRetryWaitMin = 1 * time.Second
+	// DefaultRetryWaitMax is the default maximum wait before retrying a download request.
+	DefaultRetryWaitMax = 30 * time.Second
+)
+
+// forwardedHeaders are the request headers kept when a download is redirected to another host. The range and
+// conditional headers are kept, so downloads redirected to a CDN can still be resumed and revalidated.
+var forwardedHeaders = []string{"User-Agent", "Accept", "Accept-Encoding", "Range", "If-Range", "If-None-Match"}
+
+// DownloadOptions are the options used to download a tool.
+type DownloadOptions struct {
+	// Checksums are the expected digests of the download, verified while it's written.
+	Checksums []Checksum
+	// Signature is a detached signature the download is verified against before it's saved, if it's not nil.
+	Signature *Signature
+	// Headers are extra request headers, such as API keys for private artifact repositories.
+	Headers http.Header
+	// Token is sent as a bearer token in the Authorization header, replacing any Authorization header.
+	Token string
+	// Netrc sends basic auth credentials for the host from the netrc file if there's no Authorization header.
+	Netrc bool
+	// Dest is the directory or file path to save the download to, instead of a new temporary directory.
+	Dest string
+	// Overwrite replaces an existing file at the destination.
+	Overwrite bool
+	// Progress is written throttled progress lines while downloading, if it's not nil.
+	Progress io.Writer
+	// Retry is how failed requests are retried, or the default retry policy if it's nil.
+	Retry *RetryPolicy
+	// Timeout is the overall timeout of the download, including retries and resumes, or no timeout if it's zero.
+	Timeout time.Duration
+	// MaxSize is the maximum size of the download in bytes, or no limit if it's zero.
+	MaxSize int64
+	// Cache restores the download from the download cache in the tool cache if it's cached, by the expected checksums
+	// or by URL if the server confirms its ETag hasn't changed, and otherwise adds it to the cache.
+	Cache bool
+	// CacheMaxSize is the maximum size of the download cache in bytes, or no limit if it's zero.
+	// The least recently used files are evicted when a download is cached.
+	CacheMaxSize int64
+}
+
+// RetryPolicy is how failed download requests are retried, with an exponential backoff between retries.
+type RetryPolicy struct {
+	// Max is the maximum number of retries.
+	Max int
+	// WaitMin is the minimum wait before a retry, or DefaultRetryWaitMin if it's zero.
+	WaitMin time.Duration
+	// WaitMax is the maximum wait before a retry, or DefaultRetryWaitMax if it's zero.
+	WaitMax time.Duration
+	// StatusCodes are the response statuses that are retried.
+	// If it's empty, 429 and 5xx statuses other than 501 are retried.
+	StatusCodes []int
+}
+
+// DefaultRetryPolicy returns the default retry policy of download requests.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{Max: DefaultRetries, WaitMin: DefaultRetryWaitMin, WaitMax: DefaultRetryWaitMax}
+}
+
+// DownloadResult is a downloaded tool.
+type DownloadResult struct {
+	Path string
+	// URL is the URL the tool was downloaded from, before any redirects.
+	URL string
+	// ContentType is the MIME type of the download, from the Content-Type header or detected from its content.
+	ContentType string
+	// Size is the size of the download in bytes.
+	Size int64
+	// SHA256 is the hex encoded SHA-256 digest of the download.
+	SHA256 string
+	// SHA512 is the hex encoded SHA-512 digest of the download.
+	SHA512 string
+	// Cached is true if the download was restored from the download cache.
+	Cached bool
+	// Signature is the format of the signature the download was verified against, or empty if it wasn't.
+	Signature SignatureFormat
+}
+
+// DownloadTool downloads a tool from a URL and saves it with its file name, from the Content-Disposition header or the
+// final URL path after redirects, to a new directory in the temporary directory or to the destination in the options.
+// The digests of the download are computed while it's written, and if it doesn't match the expected checksums
+// or signature in the options it's deleted and an error is returned.
+// Interrupted downloads are resumed with Range requests if the server sends an ETag or Last-Modified validator.
+// The download is aborted if it takes longer than the timeout or is larger than the maximum size in the options.
+// With the cache option, downloads are restored from and added to the download cache in the tool cache.
+func DownloadTool(ctx context.Context, logger any, url url.URL, options DownloadOptions) (*DownloadResult, error) {
+	if options.Timeout <= 0 {
+		return downloadTool(ctx, logger, url, options)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+
+	result, err := downloadTool(ctx, logger, url, options)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("download timed out after %s: %w", options.Timeout, err)
+	}
+
+	return result, err
+}
+
+// downloadTool downloads a tool from a URL, as described by DownloadTool, without the timeout.
+func downloadTool(ctx context.Context, logger any, url url.URL, options DownloadOptions) (*DownloadResult, error) {
+	for _, c := range options.Checksums {
+		if _, err := NewChecksum(c.Algorithm, c.Digest); err != nil {
+			return nil, err
+		}
+	}
+
+	d := options.Dest
+	if d == "" {
+		t, err := core.GetTempDir()
+		if err != nil {
+			return nil, err
+		}
+		d = t
+	}
+
+	cacheDir := ""
+	if options.Cache {
+		var err error
+		cacheDir, err = getDownloadCacheDirectory()
+		if err != nil {
+			return nil, err
+		}
+
+		entry, err := findCachedChecksum(cacheDir, options.Checksums)
+		if err != nil {
+			return nil, err
+		}
+
+		if entry != nil {
+			result, err := restoreCachedDownload(cacheDir, entry, d, url, options)
+			if err != nil || result != nil {
+				return result, err
+			}
+		}
+	}
+
+	req, err := newDownloadRequest(ctx, url, options)
+	if err != nil {
+		return nil, err
+	}
+
+	client := newDownloadClient(logger, options.Retry)
+
+	// A download cached by URL is only restored if the server confirms it hasn't changed.
+	var cached *downloadCacheEntry
+	if options.Cache {
+		cached, err = readDownloadCacheEntry(cacheDir, getURLCacheKey(url))
+		if err != nil {
+			return nil, err
+		}
+
+		// The conditional header is kept when the download is redirected, as the ETag is from the final response.
+		if cached != nil {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		if err := resp.Body.Close(); err != nil {
+			return nil, err
+		}
+
+		result, err := restoreCachedDownload(cacheDir, cached, d, url, options)
+		if err != nil || result != nil {
+			return result, err
+		}
+
+		// The cached file has been evicted or is corrupted, so it's downloaded again.
+		req.Header.Del("If-None-Match")
+
+		resp, err = client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Join(fmt.Errorf("unexpected status: %s", resp.Status), resp.Body.Close())
+	}
+
+	if options.MaxSize > 0 && resp.ContentLength > options.MaxSize {
+		return nil, errors.Join(newMaxSizeError(options.MaxSize), resp.Body.Close())
+	}
+
+	name := getDownloadName(resp)
+	etag := resp.Header.Get("ETag")
+
+	target, cleanup, err := createDownloadTarget(d, name, options)
+	if err != nil {
+		return nil, errors.Join(err, resp.Body.Close())
+	}
+
+	// The download is written to a partial file next to the target, and only renamed once it's verified.
+	dest, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+"-*.partial")
+	if err != nil {
+		return nil, errors.Join(err, resp.Body.Close(), removeDownloadDir(cleanup))
+	}
+	defer dest.Close()
+
+	sha256Hash, sha512Hash := sha256.New(), sha512.New()
+	contentType := resp.Header.Get("Content-Type")
+
+	progress := newDownloadProgress(options.Progress, resp.ContentLength)
+
+	if err := writeDownload(ctx, client, url, options, resp, dest, progress, sha256Hash, sha512Hash); err != nil {
+		return nil, errors.Join(err, dest.Close(), os.Remove(dest.Name()), removeDownloadDir(cleanup))
+	}
+
+	result := &DownloadResult{
+		Path:   target,
+		URL:    url.String(),
+		SHA256: hex.EncodeToString(sha256Hash.Sum(nil)),
+		SHA512: hex.EncodeToString(sha512Hash.Sum(nil)),
+	}
+
+	if err := verifyDownload(result, options.Checksums); err != nil {
+		return nil, errors.Join(err, dest.Close(), os.Remove(dest.Name()), removeDownloadDir(cleanup))
+	}
+
+	result.Signature, err = verifyDownloadSignature(dest.Name(), options.Signature)
+	if err != nil {
+		return nil, errors.Join(err, dest.Close(), os.Remove(dest.Name()), removeDownloadDir(cleanup))
+	}
+
+	result.ContentType, result.Size, err = getDownloadContent(dest, contentType)
+	if err != nil {
+		return nil, errors.Join(err, dest.Close(), os.Remove(dest.Name()), removeDownloadDir(cleanup))
+	}
+
+	if err := dest.Close(); err != nil {
+		return nil, errors.Join(err, os.Remove(dest.Name()), removeDownloadDir(cleanup))
+	}
+
+	if err := os.Rename(dest.Name(), target); err != nil {
+		return nil, errors.Join(err, os.Remove(dest.Name()), removeDownloadDir(cleanup))
+	}
+
+	// Caching is best effort, so a download that's already verified isn't lost if it can't be cached.
+	if options.Cache {
+		if err := storeDownload(cacheDir, result, url, name, etag, options.CacheMaxSize); err != nil {
+			if l, ok := logger.(retryablehttp.LeveledLogger); ok {
+				l.Warn("Caching the download failed.", "url", url.Redacted(), "error", err)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// getDownloadName returns the file name of a download from the Content-Disposition header, falling back to the last
+// element of the final URL path after redirects.
+func getDownloadName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := cleanDownloadName(params["filename"]); name != "" {
+			return name
+		}
+	}
+
+	if resp.Request != nil && resp.Request.URL != nil {
+		if name := cleanDownloadName(resp.Request.URL.Path); name != "" {
+			return name
+		}
+	}
+
+	return defaultDownloadName
+}
+
+// cleanDownloadName returns the last element of a file name from a server, or an empty string if it has no usable name.
+func cleanDownloadName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == ".." || name == "/" {
+		return ""
+	}
+
+	return name
+}
+
+// createDownloadTarget returns the path to save a download with a file name to, and the directory to remove if the
+// download fails. Without a destination in the options each download gets its own directory in the temporary
+// directory d, so it can keep its file name.
+func createDownloadTarget(d, name string, options DownloadOptions) (string, string, error) {
+	cleanup := ""
+	if options.Dest == "" {
+		var err error
+		d, err = os.MkdirTemp(d, "download-*")
+		if err != nil {
+			return "", "", err
+		}
+		cleanup = d
+	}
+
+	target, err := getDownloadTarget(d, name, options.Overwrite)
 	if err != nil {
+		return "", "", errors.Join(err, removeDownloadDir(cleanup))
+	}
+
+	return target, cleanup, nil
+}
+
+// getDownloadTarget returns the path to save a download to.
+// If dest is a directory, or ends in a path separator, the file name is joined to it; otherwise dest is the file path.
+// Missing parent directories are created, and an error is returned if the target exists and overwrite is false.
+func getDownloadTarget(dest, name string, overwrite bool) (string, error) {
+	target := dest
+	if strings.HasSuffix(dest, "/") || strings.HasSuffix(dest, string(filepath.Separator)) {
+		target = filepath.Join(dest, name)
+	} else if fi, err := os.Stat(dest); err == nil && fi.IsDir() {
+		target = filepath.Join(dest, name)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
 		return "", err
 	}
 
+	fi, err := os.Stat(target)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return target, nil
+		}
+		return "", err
+	}
+
+	if fi.IsDir() {
+		return "", fmt.Errorf("destination %s is a directory", target)
+	}
+
+	if !overwrite {
+		return "", fmt.Errorf("destination %s already exists", target)
+	}
+
+	return target, nil
+}
+
+// getDownloadContent returns the MIME type and size of a downloaded file.
+// The MIME type is from the Content-Type header, or is detected from the content if the header is missing or generic.
+func getDownloadContent(f *os.File, contentType string) (string, int64, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType != genericContentType {
+		return mediaType, fi.Size(), nil
+	}
+
+	b := make([]byte, 512)
+	n, err := f.ReadAt(b, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", 0, err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(b[:n]))
+	if err != nil {
+		return "", 0, err
+	}
+
+	return mediaType, fi.Size(), nil
+}
+
+// removeDownloadDir removes a directory created for a download, if there is one.
+func removeDownloadDir(p string) error {
+	if p == "" {
+		return nil
+	}
+
+	return os.RemoveAll(p)
+}
+
+// writeDownload writes the body of a download response to a file and hashes.
+// If the connection is interrupted and the response has a validator, the download is resumed from the bytes already
+// written with a Range request; if the server ignores the range or the file has changed it's restarted from the beginning.
+func writeDownload(ctx context.Context, client *retryablehttp.Client, u url.URL, options DownloadOptions, resp *http.Response, dest *os.File, progress *downloadProgress, hashes ...hash.Hash) error {
+	writers := []io.Writer{dest}
+	for _, h := range hashes {
+		writers = append(writers, h)
+	}
+	w := io.MultiWriter(writers...)
+
+	validator := getRangeValidator(resp)
+	written := int64(0)
+
+	for attempt := 0; ; attempt++ {
+		body := io.Reader(resp.Body)
+		if options.MaxSize > 0 {
+			// Read one byte past the maximum size, to tell a download of the maximum size from a larger one.
+			body = io.LimitReader(body, options.MaxSize-written+1)
+		}
+
+		n, err := io.Copy(w, progress.reader(body))
+		written += n
+
+		if closeErr := resp.Body.Close(); err == nil {
+			err = closeErr
+		}
+		if options.MaxSize > 0 && written > options.MaxSize {
+			return newMaxSizeError(options.MaxSize)
+		}
+		if err == nil {
+			progress.finish()
+			return nil
+		}
+
+		if ctx.Err() != nil || validator == "" || attempt >= maxResumeAttempts {
+			return err
+		}
+
+		resp, err = resumeDownload(ctx, client, u, options, written, validator)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			if err := dest.Truncate(0); err != nil {
+				return errors.Join(err, resp.Body.Close())
+			}
+
+			if _, err := dest.Seek(0, io.SeekStart); err != nil {
+				return errors.Join(err, resp.Body.Close())
+			}
+
+			for _, h := range hashes {
+				h.Reset()
+			}
+
+			validator = getRangeValidator(resp)
+			written = 0
+			progress.restart(resp.ContentLength)
+		}
+	}
+}
+
+// newMaxSizeError returns the error for a download larger than the maximum size.
+func newMaxSizeError(maxSize int64) error {
+	return fmt.Errorf("download is larger than the maximum size of %s", FormatBytes(maxSize))
+}
+
+// resumeDownload requests the rest of a download from an offset, if it still matches the validator.
+// The response is either the partial content from the offset, or the full content with a 200 status.
+func resumeDownload(ctx context.Context, client *retryablehttp.Client, u url.URL, options DownloadOptions, offset int64, validator string) (*http.Response, error) {
+	req, err := newDownloadRequest(ctx, u, options)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	req.Header.Set("If-Range", validator)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusPartialContent:
+		if !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			return nil, errors.Join(fmt.Errorf("unexpected content range resuming download: %s", resp.Header.Get("Content-Range")), resp.Body.Close())
+		}
+		return resp, nil
+	default:
+		return nil, errors.Join(fmt.Errorf("unexpected status resuming download: %s", resp.Status), resp.Body.Close())
+	}
+}
+
+// getRangeValidator returns the validator used in the If-Range header to resume a download, which is a strong ETag
+// or the Last-Modified date. It returns an empty string if the download can't be resumed.
+func getRangeValidator(resp *http.Response) string {
+	if resp.Header.Get("Accept-Ranges") == "none" {
+		return ""
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+
+	return resp.Header.Get("Last-Modified")
+}
+
+// newDownloadClient returns a retrying HTTP client, using the shared transport, that doesn't forward credentials when
+// redirected to another host.
+// Requests are retried with the retry policy, or the default retry policy if it's nil.
+func newDownloadClient(logger any, retry *RetryPolicy) *retryablehttp.Client {
+	policy := DefaultRetryPolicy()
+	if retry != nil {
+		policy = *retry
+	}
+
 	client := retryablehttp.NewClient()
 	client.Logger = logger
+	client.HTTPClient.Transport = httpclient.Transport()
+	client.HTTPClient.CheckRedirect = checkDownloadRedirect
+	client.RetryMax = policy.Max
+
+	if policy.WaitMin > 0 {
+		client.RetryWaitMin = policy.WaitMin
+	}
+
+	if policy.WaitMax > 0 {
+		client.RetryWaitMax = policy.WaitMax
+	}
+
+	if len(policy.StatusCodes) > 0 {
+		client.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+
+			// Connection errors are retried with the default policy, which doesn't retry permanent errors.
+			if err != nil || resp == nil {
+				return retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+			}
+
+			return slices.Contains(policy.StatusCodes, resp.StatusCode), nil
+		}
+	}
+
+	return client
+}
+
+// downloadSmallFile downloads a file of up to maxSize bytes, such as a checksum or signature file, into memory.
+// Only the headers, credentials, retry policy and timeout in the options are used.
+// It returns fs.ErrNotExist if the server responds 404 Not Found.
+func downloadSmallFile(ctx context.Context, logger any, u url.URL, options DownloadOptions, maxSize int64) ([]byte, error) {
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
 
-	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+	req, err := newDownloadRequest(ctx, u, options)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	resp, err := client.Do(req)
+	resp, err := newDownloadClient(logger, options.Retry).Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fs.ErrNotExist
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status: %s", resp.Status)
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
 	}
 
-	dest, err := os.CreateTemp(d, "tool-*")
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer dest.Close()
 
-	if _, err := dest.ReadFrom(resp.Body); err != nil {
-		return "", err
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxSize)
+	}
+
+	return data, nil
+}
+
+// newDownloadRequest returns a GET request for a URL with the headers and credentials in the options.
+func newDownloadRequest(ctx context.Context, u url.URL, options DownloadOptions) (*retryablehttp.Request, error) {
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, vs := range options.Headers {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+
+	if options.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+options.Token)
+	}
+
+	if options.Netrc && req.Header.Get("Authorization") == "" {
+		creds, err := readNetrc(u.Hostname())
+		if err != nil {
+			return nil, err
+		}
+
+		if creds != nil {
+			req.SetBasicAuth(creds.Login, creds.Password)
+		}
+	}
+
+	return req, nil
+}
+
+// checkDownloadRedirect stops after 10 redirects, and removes the request headers set from the download options
+// when a redirect goes to another host or from HTTPS to HTTP, so credentials are only sent to the original host.
+func checkDownloadRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+
+	original := via[0]
+	if req.URL.Host == original.URL.Host && (req.URL.Scheme == original.URL.Scheme || req.URL.Scheme == "https") {
+		return nil
+	}
+
+	// The redirect request copies the original request's headers, which aren't all safe to forward.
+	header := http.Header{}
+	for _, k := range forwardedHeaders {
+		if v, ok := req.Header[k]; ok {
+			header[k] = v
+		}
+	}
+	req.Header = header
+
+	return nil
+}
+
+// verifyDownload returns an error if a download doesn't match all of the expected checksums.
+func verifyDownload(result *DownloadResult, checksums []Checksum) error {
+	for _, c := range checksums {
+		expected, err := NewChecksum(c.Algorithm, c.Digest)
+		if err != nil {
+			return err
+		}
+
+		actual := result.SHA256
+		if expected.Algorithm == SHA512 {
+			actual = result.SHA512
+		}
+
+		if actual != expected.Digest {
+			return fmt.Errorf("%s checksum mismatch: expected %s, got %s", expected.Algorithm, expected.Digest, actual)
+		}
+	}
+
+	return nil
+}
+
+// verifyDownloadSignature verifies the file at a path against a signature, if it's not nil, and returns its format.
+func verifyDownloadSignature(p string, signature *Signature) (SignatureFormat, error) {
+	if signature == nil {
+		return "", nil
 	}
 
-	return dest.Name(), nil
+	return VerifySignature(p, *signature)
 }
@@ -1,15 +1,24 @@
 package toolcache
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/matryer/is"
 
 	"github.com/action-stars/ghactl/internal/fileio"
+	"github.com/action-stars/ghactl/internal/toolkit/httpclient"
 )
 
 func TestDownloadTool(t *testing.T) {
@@ -30,7 +39,7 @@ func TestDownloadTool(t *testing.T) {
 		wantErr  bool
 	}{
 		{
-			name:    "errors_if_temp_dir_is_not_defined",
+			name:    "errors_if_no_temp_dir_is_found",
 			temp:    "",
 			url:     "http://example.com/file",
 			wantErr: true,
@@ -58,9 +67,10 @@ func TestDownloadTool(t *testing.T) {
 			t.Setenv("RUNNER_TEMP", temp)
 			t.Setenv("TEMPDIR", "")
 			t.Setenv("TMP", "")
+			t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "non-existent"))
 
 			u, _ := url.Parse(tt.url)
-			p, err := DownloadTool(t.Context(), nil, *u)
+			result, err := DownloadTool(t.Context(), nil, *u, DownloadOptions{})
 
 			if tt.wantErr {
 				is.True(err != nil) // should error
@@ -70,9 +80,563 @@ func TestDownloadTool(t *testing.T) {
 			is.NoErr(err) // should not error
 
 			if tt.wantFile {
-				fileExists, _ := fileio.FileExists(p)
+				fileExists, _ := fileio.FileExists(result.Path)
 				is.True(fileExists) // should exist
 			}
 		})
 	}
 }
+
+func TestDownloadTool_tls(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "file content")
+	}))
+	defer ts.Close()
+
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	mustCreateTestFile(t, caCert, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})))
+
+	tests := []struct {
+		name    string
+		options httpclient.TLSOptions
+		wantErr bool
+	}{
+		{
+			name:    "errors_on_untrusted_certificate",
+			wantErr: true,
+		},
+		{
+			name:    "trusts_configured_ca_bundle",
+			options: httpclient.TLSOptions{CACert: caCert},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			t.Setenv("RUNNER_TEMP", t.TempDir())
+			t.Cleanup(func() { _ = httpclient.Configure(httpclient.TLSOptions{}) })
+
+			err := httpclient.Configure(tt.options)
+			is.NoErr(err) // should configure the transport
+
+			u, _ := url.Parse(ts.URL + "/file")
+			_, err = DownloadTool(t.Context(), nil, *u, DownloadOptions{Retry: &RetryPolicy{}})
+
+			is.Equal(err != nil, tt.wantErr) // should only trust the configured CA bundle
+		})
+	}
+}
+
+func TestDownloadTool_checksums(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "file content")
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name      string
+		checksums []Checksum
+		wantErr   bool
+	}{
+		{
+			name: "returns_digests_without_checksums",
+		},
+		{
+			name:      "verifies_matching_checksums",
+			checksums: []Checksum{{Algorithm: SHA256, Digest: testSHA256}, {Algorithm: SHA512, Digest: testSHA512}},
+		},
+		{
+			name:      "errors_on_sha256_mismatch",
+			checksums: []Checksum{{Algorithm: SHA256, Digest: strings.Repeat("0", 64)}},
+			wantErr:   true,
+		},
+		{
+			name:      "errors_on_sha512_mismatch",
+			checksums: []Checksum{{Algorithm: SHA256, Digest: testSHA256}, {Algorithm: SHA512, Digest: strings.Repeat("0", 128)}},
+			wantErr:   true,
+		},
+		{
+			name:      "errors_on_invalid_checksum",
+			checksums: []Checksum{{Algorithm: SHA256, Digest: "abc"}},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			temp := t.TempDir()
+			t.Setenv("RUNNER_TEMP", temp)
+
+			u, _ := url.Parse(ts.URL + "/file")
+			result, err := DownloadTool(t.Context(), nil, *u, DownloadOptions{Checksums: tt.checksums})
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+
+				items, _ := os.ReadDir(temp)
+				is.Equal(len(items), 0) // should delete the download
+				return
+			}
+
+			is.NoErr(err)                       // should not error
+			is.Equal(result.SHA256, testSHA256) // should return the SHA-256 digest
+			is.Equal(result.SHA512, testSHA512) // should return the SHA-512 digest
+		})
+	}
+}
+
+func TestDownloadTool_credentials(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" || r.Header.Get("X-Api-Key") != "" || r.Header.Get("X-Creds") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, "file content")
+	}))
+	defer other.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, other.URL+"/file", http.StatusFound)
+			return
+		}
+		fmt.Fprintf(w, "%s|%s", r.Header.Get("Authorization"), r.Header.Get("X-Api-Key"))
+	}))
+	defer ts.Close()
+
+	netrc := filepath.Join(t.TempDir(), ".netrc")
+	mustCreateTestFile(t, netrc, "machine 127.0.0.1 login user password secret\n")
+
+	tests := []struct {
+		name    string
+		path    string
+		options DownloadOptions
+		want    string
+	}{
+		{
+			name:    "sends_headers",
+			path:    "/file",
+			options: DownloadOptions{Headers: http.Header{"X-Api-Key": {"key"}}},
+			want:    "|key",
+		},
+		{
+			name:    "sends_bearer_token",
+			path:    "/file",
+			options: DownloadOptions{Headers: http.Header{"Authorization": {"Basic abc"}}, Token: "token"},
+			want:    "Bearer token|",
+		},
+		{
+			name:    "sends_netrc_credentials",
+			path:    "/file",
+			options: DownloadOptions{Netrc: true},
+			want:    "Basic dXNlcjpzZWNyZXQ=|",
+		},
+		{
+			name:    "prefers_token_over_netrc",
+			path:    "/file",
+			options: DownloadOptions{Token: "token", Netrc: true},
+			want:    "Bearer token|",
+		},
+		{
+			name:    "does_not_forward_credentials_to_other_host",
+			path:    "/redirect",
+			options: DownloadOptions{Headers: http.Header{"X-Api-Key": {"key"}}, Token: "token"},
+			want:    "file content",
+		},
+		{
+			name:    "does_not_forward_custom_headers_to_other_host",
+			path:    "/redirect",
+			options: DownloadOptions{Headers: http.Header{"X-Creds": {"secret"}}},
+			want:    "file content",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			t.Setenv("RUNNER_TEMP", t.TempDir())
+			t.Setenv("NETRC", netrc)
+
+			u, _ := url.Parse(ts.URL + tt.path)
+			result, err := DownloadTool(t.Context(), nil, *u, tt.options)
+
+			is.NoErr(err) // should not error
+
+			b, _ := os.ReadFile(result.Path)
+			is.Equal(string(b), tt.want) // should match expected
+		})
+	}
+}
+
+// interruptingWriter writes up to a limit, and then flushes and aborts the connection.
+type interruptingWriter struct {
+	http.ResponseWriter
+	limit int
+}
+
+func (w *interruptingWriter) Write(b []byte) (int, error) {
+	if len(b) > w.limit {
+		n, _ := w.ResponseWriter.Write(b[:w.limit])
+		w.limit -= n
+		w.ResponseWriter.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.limit -= n
+	return n, err
+}
+
+func TestDownloadTool_resume(t *testing.T) {
+	content := strings.Repeat("0123456789", 10000)
+	sum := sha256.Sum256([]byte(content))
+	digest := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name         string
+		etag         func(request int) string
+		ignoreRanges bool
+		redirect     bool
+		interrupts   int
+		wantRanges   []string
+		wantErr      bool
+	}{
+		{
+			name:       "resumes_interrupted_download",
+			etag:       func(int) string { return `"v1"` },
+			interrupts: 2,
+			wantRanges: []string{"", "bytes=10000-", "bytes=20000-"},
+		},
+		{
+			name:       "resumes_interrupted_download_behind_redirect",
+			etag:       func(int) string { return `"v1"` },
+			redirect:   true,
+			interrupts: 2,
+			wantRanges: []string{"", "bytes=10000-", "bytes=20000-"},
+		},
+		{
+			name:         "restarts_if_server_ignores_ranges",
+			etag:         func(int) string { return `"v1"` },
+			ignoreRanges: true,
+			interrupts:   1,
+			wantRanges:   []string{"", "bytes=10000-"},
+		},
+		{
+			name:       "restarts_if_file_changes",
+			etag:       func(request int) string { return fmt.Sprintf(`"v%d"`, request) },
+			interrupts: 1,
+			wantRanges: []string{"", "bytes=10000-"},
+		},
+		{
+			name:       "errors_without_validator",
+			etag:       func(int) string { return "" },
+			interrupts: 1,
+			wantRanges: []string{""},
+			wantErr:    true,
+		},
+		{
+			name:       "errors_after_too_many_interruptions",
+			etag:       func(int) string { return `"v1"` },
+			interrupts: maxResumeAttempts + 1,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			temp := t.TempDir()
+			t.Setenv("RUNNER_TEMP", temp)
+
+			ranges := []string{}
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				request := len(ranges)
+				ranges = append(ranges, r.Header.Get("Range"))
+
+				if etag := tt.etag(request); etag != "" {
+					w.Header().Set("ETag", etag)
+				}
+				if tt.ignoreRanges {
+					r.Header.Del("Range")
+				}
+				if request < tt.interrupts {
+					w = &interruptingWriter{ResponseWriter: w, limit: 10000}
+				}
+
+				http.ServeContent(w, r, "tool", time.Time{}, strings.NewReader(content))
+			}))
+			defer ts.Close()
+
+			rawURL := ts.URL + "/tool"
+			if tt.redirect {
+				// Release assets redirect to a CDN on another host.
+				front := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					http.Redirect(w, r, ts.URL+r.URL.Path, http.StatusFound)
+				}))
+				defer front.Close()
+				rawURL = front.URL + "/tool"
+			}
+
+			u, _ := url.Parse(rawURL)
+			result, err := DownloadTool(t.Context(), nil, *u, DownloadOptions{Token: "token"})
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+
+				items, _ := os.ReadDir(temp)
+				is.Equal(len(items), 0) // should delete the download
+				return
+			}
+
+			is.NoErr(err)                   // should not error
+			is.Equal(result.SHA256, digest) // should hash the full content
+			is.Equal(ranges, tt.wantRanges) // should request the expected ranges
+
+			b, _ := os.ReadFile(result.Path)
+			is.Equal(string(b), content) // should write the full content
+		})
+	}
+}
+
+func TestDownloadTool_dest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/releases/tool_1.0.0_linux_amd64.tar.gz", http.StatusFound)
+			return
+		case "/attachment":
+			w.Header().Set("Content-Disposition", `attachment; filename="../tool_1.0.0_linux_amd64.zip"`)
+			w.Header().Set("Content-Type", "application/zip")
+		case "/":
+			w.Header().Set("Content-Type", "application/octet-stream")
+		}
+		fmt.Fprint(w, "file content")
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name            string
+		path            string
+		dest            func(dir string) string
+		existing        bool
+		overwrite       bool
+		wantName        string
+		wantContentType string
+		wantErr         bool
+	}{
+		{
+			name:            "uses_final_url_path_after_redirects",
+			path:            "/redirect",
+			wantName:        "tool_1.0.0_linux_amd64.tar.gz",
+			wantContentType: "text/plain",
+		},
+		{
+			name:            "uses_content_disposition_file_name",
+			path:            "/attachment",
+			wantName:        "tool_1.0.0_linux_amd64.zip",
+			wantContentType: "application/zip",
+		},
+		{
+			name:            "falls_back_to_default_name",
+			path:            "/",
+			wantName:        "download",
+			wantContentType: "text/plain",
+		},
+		{
+			name:            "saves_to_dest_dir",
+			path:            "/attachment",
+			dest:            func(dir string) string { return dir },
+			wantName:        "tool_1.0.0_linux_amd64.zip",
+			wantContentType: "application/zip",
+		},
+		{
+			name:            "saves_to_new_dest_dir_with_trailing_separator",
+			path:            "/attachment",
+			dest:            func(dir string) string { return filepath.Join(dir, "new") + string(filepath.Separator) },
+			wantName:        "tool_1.0.0_linux_amd64.zip",
+			wantContentType: "application/zip",
+		},
+		{
+			name:            "saves_to_dest_file",
+			path:            "/attachment",
+			dest:            func(dir string) string { return filepath.Join(dir, "tool.zip") },
+			wantName:        "tool.zip",
+			wantContentType: "application/zip",
+		},
+		{
+			name:     "errors_if_dest_exists",
+			path:     "/attachment",
+			dest:     func(dir string) string { return filepath.Join(dir, "tool.zip") },
+			existing: true,
+			wantErr:  true,
+		},
+		{
+			name:            "overwrites_existing_dest",
+			path:            "/attachment",
+			dest:            func(dir string) string { return filepath.Join(dir, "tool.zip") },
+			existing:        true,
+			overwrite:       true,
+			wantName:        "tool.zip",
+			wantContentType: "application/zip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			t.Setenv("RUNNER_TEMP", t.TempDir())
+
+			options := DownloadOptions{Overwrite: tt.overwrite}
+			if tt.dest != nil {
+				dir := t.TempDir()
+				options.Dest = tt.dest(dir)
+
+				if tt.existing {
+					mustCreateTestFile(t, options.Dest, "existing")
+				}
+			}
+
+			u, _ := url.Parse(ts.URL + tt.path)
+			result, err := DownloadTool(t.Context(), nil, *u, options)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+
+				b, _ := os.ReadFile(options.Dest)
+				is.Equal(string(b), "existing") // should not replace the existing file
+
+				items, _ := os.ReadDir(filepath.Dir(options.Dest))
+				is.Equal(len(items), 1) // should not leave a partial file
+				return
+			}
+
+			is.NoErr(err)                                     // should not error
+			is.Equal(filepath.Base(result.Path), tt.wantName) // should use the expected name
+			is.Equal(result.ContentType, tt.wantContentType)  // should return the MIME type
+			is.Equal(result.Size, int64(len("file content"))) // should return the size
+
+			if options.Dest != "" {
+				is.True(strings.HasPrefix(result.Path, filepath.Dir(filepath.Clean(options.Dest)))) // should save to the destination
+			}
+
+			items, _ := os.ReadDir(filepath.Dir(result.Path))
+			is.Equal(len(items), 1) // should not leave a partial file
+
+			b, _ := os.ReadFile(result.Path)
+			is.Equal(string(b), "file content") // should write the content
+		})
+	}
+}
+
+func TestDownloadTool_limits(t *testing.T) {
+	noWait := func(max int, statusCodes ...int) *RetryPolicy {
+		return &RetryPolicy{Max: max, WaitMin: time.Millisecond, WaitMax: time.Millisecond, StatusCodes: statusCodes}
+	}
+
+	tests := []struct {
+		name         string
+		statuses     []int
+		chunked      bool
+		slow         bool
+		options      DownloadOptions
+		wantRequests int
+		wantErr      string
+	}{
+		{
+			name:         "retries_configured_status_codes",
+			statuses:     []int{http.StatusTeapot, http.StatusTeapot},
+			options:      DownloadOptions{Retry: noWait(2, http.StatusTeapot)},
+			wantRequests: 3,
+		},
+		{
+			name:         "does_not_retry_other_status_codes",
+			statuses:     []int{http.StatusServiceUnavailable},
+			options:      DownloadOptions{Retry: noWait(2, http.StatusTeapot)},
+			wantRequests: 1,
+			wantErr:      "503",
+		},
+		{
+			name:         "gives_up_after_max_retries",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			options:      DownloadOptions{Retry: noWait(1)},
+			wantRequests: 2,
+			wantErr:      "giving up after 2 attempt(s)",
+		},
+		{
+			name:         "allows_download_of_max_size",
+			options:      DownloadOptions{MaxSize: 12},
+			wantRequests: 1,
+		},
+		{
+			name:         "errors_if_content_length_is_larger_than_max_size",
+			options:      DownloadOptions{MaxSize: 11},
+			wantRequests: 1,
+			wantErr:      "larger than the maximum size of 11 B",
+		},
+		{
+			name:         "errors_if_body_is_larger_than_max_size",
+			chunked:      true,
+			options:      DownloadOptions{MaxSize: 11},
+			wantRequests: 1,
+			wantErr:      "larger than the maximum size of 11 B",
+		},
+		{
+			name:         "errors_after_timeout",
+			slow:         true,
+			options:      DownloadOptions{Retry: noWait(0), Timeout: 50 * time.Millisecond},
+			wantRequests: 1,
+			wantErr:      "download timed out after 50ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			temp := t.TempDir()
+			t.Setenv("RUNNER_TEMP", temp)
+
+			var requests atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				request := int(requests.Add(1))
+
+				if request <= len(tt.statuses) {
+					w.WriteHeader(tt.statuses[request-1])
+					return
+				}
+
+				if tt.slow {
+					select {
+					case <-r.Context().Done():
+					case <-time.After(time.Second):
+					}
+					return
+				}
+
+				if tt.chunked {
+					fmt.Fprint(w, "file ")
+					w.(http.Flusher).Flush()
+				}
+				fmt.Fprint(w, "file content")
+			}))
+			defer ts.Close()
+
+			u, _ := url.Parse(ts.URL + "/tool")
+			_, err := DownloadTool(t.Context(), nil, *u, tt.options)
+
+			is.Equal(int(requests.Load()), tt.wantRequests) // should make the expected requests
+
+			if tt.wantErr != "" {
+				is.True(err != nil)                                // should error
+				is.True(strings.Contains(err.Error(), tt.wantErr)) // should return the expected error
+
+				items, _ := os.ReadDir(temp)
+				is.Equal(len(items), 0) // should delete the download
+				return
+			}
+
+			is.NoErr(err) // should not error
+		})
+	}
+}
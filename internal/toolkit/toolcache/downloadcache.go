@@ -0,0 +1,431 @@
+package toolcache
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/action-stars/ghactl/internal/fileio"
+)
+
+// downloadCacheDirName is the directory in the tool cache with cached downloads.
+// It starts with a dot so it's not listed as a tool.
+const downloadCacheDirName = ".downloads"
+
+// downloadCacheEntry is an index entry of the download cache, pointing a key to a cached file.
+// Files are cached by their SHA-256 digest, and are indexed by their SHA-256 and SHA-512 digests and by URL and ETag.
+type downloadCacheEntry struct {
+	SHA256 string `json:"sha256"`
+	Name   string `json:"name"`
+	URL    string `json:"url,omitempty"`
+	ETag   string `json:"etag,omitempty"`
+}
+
+// ClearDownloadCache removes all the files in the download cache.
+// It returns the number of files removed and their total size.
+func ClearDownloadCache() (int, int64, error) {
+	cacheDir, err := getDownloadCacheDirectory()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	objects, err := listDownloadCacheObjects(cacheDir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	size := int64(0)
+	for _, o := range objects {
+		size += o.Size()
+	}
+
+	if err := os.RemoveAll(cacheDir); err != nil {
+		return 0, 0, err
+	}
+
+	return len(objects), size, nil
+}
+
+// getDownloadCacheDirectory returns the download cache directory in the GitHub Actions runner tool cache.
+func getDownloadCacheDirectory() (string, error) {
+	cacheDir, err := GetToolCacheDirectory()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cacheDir, downloadCacheDirName), nil
+}
+
+// getDownloadCacheObjectPath returns the path of a cached file with a SHA-256 digest.
+func getDownloadCacheObjectPath(cacheDir, digest string) string {
+	return filepath.Join(cacheDir, "objects", digest)
+}
+
+// getDownloadCacheIndexPath returns the path of the index entry for a key.
+func getDownloadCacheIndexPath(cacheDir, key string) string {
+	return filepath.Join(cacheDir, "index", key+".json")
+}
+
+// getChecksumCacheKey returns the download cache key for a checksum.
+func getChecksumCacheKey(c Checksum) string {
+	return c.Algorithm + "-" + c.Digest
+}
+
+// getURLCacheKey returns the download cache key for a URL.
+func getURLCacheKey(u url.URL) string {
+	sum := sha256.Sum256([]byte(u.String()))
+	return "url-" + hex.EncodeToString(sum[:])
+}
+
+// findCachedChecksum returns the index entry of a cached file matching one of the checksums, or nil if none is cached.
+func findCachedChecksum(cacheDir string, checksums []Checksum) (*downloadCacheEntry, error) {
+	for _, c := range checksums {
+		entry, err := readDownloadCacheEntry(cacheDir, getChecksumCacheKey(c))
+		if err != nil || entry != nil {
+			return entry, err
+		}
+	}
+
+	return nil, nil
+}
+
+// readDownloadCacheEntry reads the index entry for a key, or returns nil if there isn't one.
+func readDownloadCacheEntry(cacheDir, key string) (*downloadCacheEntry, error) {
+	data, err := os.ReadFile(getDownloadCacheIndexPath(cacheDir, key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	entry := &downloadCacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil || entry.SHA256 == "" {
+		// A corrupted entry is a cache miss, and is replaced when the file is cached again.
+		return nil, nil
+	}
+
+	return entry, nil
+}
+
+// writeDownloadCacheEntry writes the index entry for a key, replacing any existing entry.
+func writeDownloadCacheEntry(cacheDir, key string, entry downloadCacheEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	p := getDownloadCacheIndexPath(cacheDir, key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	// The entry is written to a temporary file and renamed, so concurrent jobs never read a partial entry.
+	f, err := os.CreateTemp(filepath.Dir(p), ".entry-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return errors.Join(err, f.Close(), os.Remove(f.Name()))
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Join(err, os.Remove(f.Name()))
+	}
+
+	return errors.Join(os.Rename(f.Name(), p), removeIfExists(f.Name()))
+}
+
+// storeDownload adds a downloaded file to the download cache, indexed by its digests and, if it has an ETag, its URL.
+// The file is copied, not linked, so changes to the download, which may be the user's destination, don't change the cache.
+// The least recently used files are then evicted to keep the cache under the maximum size, if it's not zero.
+func storeDownload(cacheDir string, result *DownloadResult, u url.URL, name, etag string, maxSize int64) error {
+	objectPath := getDownloadCacheObjectPath(cacheDir, result.SHA256)
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0o755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(objectPath); errors.Is(err, fs.ErrNotExist) {
+		if err := copyFile(result.Path, objectPath); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if err := touchFile(objectPath); err != nil {
+		return err
+	}
+
+	entry := downloadCacheEntry{SHA256: result.SHA256, Name: name}
+	for _, c := range []Checksum{{Algorithm: SHA256, Digest: result.SHA256}, {Algorithm: SHA512, Digest: result.SHA512}} {
+		if err := writeDownloadCacheEntry(cacheDir, getChecksumCacheKey(c), entry); err != nil {
+			return err
+		}
+	}
+
+	if etag != "" {
+		entry.URL = u.String()
+		entry.ETag = etag
+
+		if err := writeDownloadCacheEntry(cacheDir, getURLCacheKey(u), entry); err != nil {
+			return err
+		}
+	}
+
+	return evictDownloadCache(cacheDir, maxSize)
+}
+
+// restoreCachedDownload links or copies a cached file to the target of a download, verifying it against its digest
+// and the expected checksums and signature in the options. It returns nil if the file is no longer cached or is
+// corrupted, in which case it's removed from the cache.
+func restoreCachedDownload(cacheDir string, entry *downloadCacheEntry, d string, u url.URL, options DownloadOptions) (*DownloadResult, error) {
+	objectPath := getDownloadCacheObjectPath(cacheDir, entry.SHA256)
+
+	sha256Digest, sha512Digest, err := hashFile(objectPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if sha256Digest != entry.SHA256 {
+		return nil, os.Remove(objectPath)
+	}
+
+	result := &DownloadResult{
+		URL:    u.String(),
+		SHA256: sha256Digest,
+		SHA512: sha512Digest,
+		Cached: true,
+	}
+
+	if err := verifyDownload(result, options.Checksums); err != nil {
+		return nil, err
+	}
+
+	result.Signature, err = verifyDownloadSignature(objectPath, options.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	name := cleanDownloadName(entry.Name)
+	if name == "" {
+		name = cleanDownloadName(u.Path)
+	}
+	if name == "" {
+		name = defaultDownloadName
+	}
+
+	target, cleanup, err := createDownloadTarget(d, name, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// The file is linked or copied to a partial file next to the target, and renamed, so an existing file is only
+	// replaced once the file is restored. It's only linked to a new temporary directory, as a file at the user's
+	// destination could be changed, changing the cached file with it.
+	partial, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+"-*.partial")
+	if err != nil {
+		return nil, errors.Join(err, removeDownloadDir(cleanup))
+	}
+
+	if err := errors.Join(partial.Close(), os.Remove(partial.Name())); err != nil {
+		return nil, errors.Join(err, removeDownloadDir(cleanup))
+	}
+
+	restore := copyFile
+	if cleanup != "" {
+		restore = linkOrCopyFile
+	}
+
+	if err := restore(objectPath, partial.Name()); err != nil {
+		return nil, errors.Join(err, removeIfExists(partial.Name()), removeDownloadDir(cleanup))
+	}
+
+	if err := os.Rename(partial.Name(), target); err != nil {
+		return nil, errors.Join(err, os.Remove(partial.Name()), removeDownloadDir(cleanup))
+	}
+
+	f, err := os.Open(target)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	result.Path = target
+	result.ContentType, result.Size, err = getDownloadContent(f, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return result, touchFile(objectPath)
+}
+
+// evictDownloadCache removes the least recently used files from the download cache until its size is under the
+// maximum size, and then removes the index entries of files that are no longer cached. A zero maximum size is no limit.
+func evictDownloadCache(cacheDir string, maxSize int64) error {
+	if maxSize <= 0 {
+		return nil
+	}
+
+	objects, err := listDownloadCacheObjects(cacheDir)
+	if err != nil {
+		return err
+	}
+
+	// Files are touched when they're cached or restored, so the most recently modified are the most recently used.
+	sort.SliceStable(objects, func(i, j int) bool {
+		return objects[i].ModTime().After(objects[j].ModTime())
+	})
+
+	size := int64(0)
+	evicted := false
+	for _, o := range objects {
+		size += o.Size()
+		if size <= maxSize {
+			continue
+		}
+
+		if err := removeIfExists(getDownloadCacheObjectPath(cacheDir, o.Name())); err != nil {
+			return err
+		}
+		evicted = true
+	}
+
+	if !evicted {
+		return nil
+	}
+
+	items, err := os.ReadDir(filepath.Join(cacheDir, "index"))
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		key, ok := strings.CutSuffix(item.Name(), ".json")
+		if !ok {
+			continue
+		}
+
+		entry, err := readDownloadCacheEntry(cacheDir, key)
+		if err != nil {
+			return err
+		}
+
+		if entry != nil {
+			if _, err := os.Stat(getDownloadCacheObjectPath(cacheDir, entry.SHA256)); err == nil {
+				continue
+			}
+		}
+
+		if err := removeIfExists(filepath.Join(cacheDir, "index", item.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listDownloadCacheObjects returns the files in the download cache.
+func listDownloadCacheObjects(cacheDir string) ([]fs.FileInfo, error) {
+	items, err := os.ReadDir(filepath.Join(cacheDir, "objects"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []fs.FileInfo{}, nil
+		}
+		return nil, err
+	}
+
+	objects := []fs.FileInfo{}
+	for _, item := range items {
+		// Skip the temporary files of copies in progress.
+		if !item.Type().IsRegular() || strings.HasPrefix(item.Name(), ".") {
+			continue
+		}
+
+		fi, err := item.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		objects = append(objects, fi)
+	}
+
+	return objects, nil
+}
+
+// linkOrCopyFile hard links a file, or copies it if it can't be linked, such as across file systems.
+func linkOrCopyFile(src, dest string) error {
+	if err := os.Link(src, dest); err == nil || errors.Is(err, fs.ErrExist) {
+		return nil
+	}
+
+	return copyFile(src, dest)
+}
+
+// copyFile copies a file to a temporary file next to the destination and renames it, so a partial copy is never at
+// the destination.
+func copyFile(src, dest string) error {
+	f, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+"-*")
+	if err != nil {
+		return err
+	}
+
+	if err := errors.Join(f.Close(), fileio.CopyFile(src, f.Name(), true)); err != nil {
+		return errors.Join(err, os.Remove(f.Name()))
+	}
+
+	return errors.Join(os.Rename(f.Name(), dest), removeIfExists(f.Name()))
+}
+
+// hashFile returns the hex encoded SHA-256 and SHA-512 digests of a file.
+func hashFile(p string) (string, string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	sha256Hash, sha512Hash := sha256.New(), sha512.New()
+	if _, err := io.Copy(io.MultiWriter(sha256Hash, sha512Hash), f); err != nil {
+		return "", "", err
+	}
+
+	return hex.EncodeToString(sha256Hash.Sum(nil)), hex.EncodeToString(sha512Hash.Sum(nil)), nil
+}
+
+// touchFile sets the modification time of a file to now, marking it as recently used.
+func touchFile(p string) error {
+	now := time.Now()
+	if err := os.Chtimes(p, now, now); err != nil {
+		return fmt.Errorf("marking cached download as used: %w", err)
+	}
+
+	return nil
+}
+
+// removeIfExists removes a file, ignoring an error if it doesn't exist.
+func removeIfExists(p string) error {
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package toolcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestDownloadTool_cache(t *testing.T) {
+	digest := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	tests := []struct {
+		name         string
+		etags        []string
+		checksum     bool
+		corrupt      bool
+		redirect     bool
+		wantRequests int32
+		wantCached   bool
+	}{
+		{
+			name:         "restores_by_checksum_without_request",
+			checksum:     true,
+			wantRequests: 1,
+			wantCached:   true,
+		},
+		{
+			name:         "restores_by_url_if_etag_matches",
+			etags:        []string{`"v1"`, `"v1"`},
+			wantRequests: 2,
+			wantCached:   true,
+		},
+		{
+			name:         "restores_by_url_behind_redirect_if_etag_matches",
+			etags:        []string{`"v1"`, `"v1"`},
+			redirect:     true,
+			wantRequests: 2,
+			wantCached:   true,
+		},
+		{
+			name:         "downloads_again_if_etag_changes",
+			etags:        []string{`"v1"`, `"v2"`},
+			wantRequests: 2,
+		},
+		{
+			name:         "downloads_again_without_etag_or_checksum",
+			wantRequests: 2,
+		},
+		{
+			name:         "downloads_again_if_cached_file_is_corrupted",
+			checksum:     true,
+			corrupt:      true,
+			wantRequests: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+			t.Setenv("RUNNER_TEMP", t.TempDir())
+
+			var requests atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				request := int(requests.Add(1))
+
+				if request <= len(tt.etags) {
+					etag := tt.etags[request-1]
+					w.Header().Set("ETag", etag)
+
+					if r.Header.Get("If-None-Match") == etag {
+						w.WriteHeader(http.StatusNotModified)
+						return
+					}
+				}
+				fmt.Fprint(w, "file content")
+			}))
+			defer ts.Close()
+
+			rawURL := ts.URL + "/tool.tar.gz"
+			if tt.redirect {
+				// Release assets redirect to a CDN on another host.
+				front := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					http.Redirect(w, r, ts.URL+r.URL.Path, http.StatusFound)
+				}))
+				defer front.Close()
+				rawURL = front.URL + "/tool.tar.gz"
+			}
+
+			options := DownloadOptions{Cache: true}
+			if tt.checksum {
+				options.Checksums = []Checksum{{Algorithm: SHA256, Digest: digest("file content")}}
+			}
+
+			u, _ := url.Parse(rawURL)
+			first, err := DownloadTool(t.Context(), nil, *u, options)
+			is.NoErr(err)          // should not error
+			is.True(!first.Cached) // should download the first time
+
+			if tt.corrupt {
+				cacheDir, _ := getDownloadCacheDirectory()
+				mustCreateTestFile(t, getDownloadCacheObjectPath(cacheDir, first.SHA256), "corrupted")
+			}
+
+			options.Dest = t.TempDir()
+			second, err := DownloadTool(t.Context(), nil, *u, options)
+
+			is.NoErr(err)                                                     // should not error
+			is.Equal(requests.Load(), tt.wantRequests)                        // should make the expected requests
+			is.Equal(second.Cached, tt.wantCached)                            // should restore from the cache
+			is.Equal(second.SHA256, first.SHA256)                             // should have the same digest
+			is.Equal(second.Path, filepath.Join(options.Dest, "tool.tar.gz")) // should keep the file name
+
+			b, _ := os.ReadFile(second.Path)
+			is.Equal(string(b), "file content") // should write the content
+		})
+	}
+}
+
+func TestDownloadTool_cacheIsolation(t *testing.T) {
+	sum := sha256.Sum256([]byte("file content"))
+	checksums := []Checksum{{Algorithm: SHA256, Digest: hex.EncodeToString(sum[:])}}
+
+	t.Run("does_not_change_cache_if_dest_file_is_changed", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		t.Setenv("RUNNER_TEMP", t.TempDir())
+
+		var requests atomic.Int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests.Add(1)
+			fmt.Fprint(w, "file content")
+		}))
+		defer ts.Close()
+
+		u, _ := url.Parse(ts.URL + "/tool")
+		first, err := DownloadTool(t.Context(), nil, *u, DownloadOptions{Cache: true, Checksums: checksums, Dest: t.TempDir()})
+		is.NoErr(err) // should not error
+
+		// The file is changed in place, as a hard link to the cached file would be.
+		mustCreateTestFile(t, first.Path, "edited content")
+
+		second, err := DownloadTool(t.Context(), nil, *u, DownloadOptions{Cache: true, Checksums: checksums, Dest: t.TempDir()})
+		is.NoErr(err)                       // should not error
+		is.True(second.Cached)              // should restore from the cache
+		is.Equal(requests.Load(), int32(1)) // should not download again
+
+		b, _ := os.ReadFile(second.Path)
+		is.Equal(string(b), "file content") // should restore the original content
+
+		cacheDir, _ := getDownloadCacheDirectory()
+		object, err := os.Stat(getDownloadCacheObjectPath(cacheDir, second.SHA256))
+		is.NoErr(err) // should not error
+
+		restored, err := os.Stat(second.Path)
+		is.NoErr(err)                           // should not error
+		is.True(!os.SameFile(object, restored)) // should not link the cached file to the destination
+	})
+
+	t.Run("returns_download_if_caching_fails", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		t.Setenv("RUNNER_TEMP", t.TempDir())
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "file content")
+		}))
+		defer ts.Close()
+
+		// A file where the objects directory should be stops the download from being cached.
+		cacheDir, _ := getDownloadCacheDirectory()
+		is.NoErr(os.MkdirAll(cacheDir, 0o755)) // should create the download cache directory
+		mustCreateTestFile(t, filepath.Join(cacheDir, "objects"), "")
+
+		u, _ := url.Parse(ts.URL + "/tool")
+		result, err := DownloadTool(t.Context(), nil, *u, DownloadOptions{Cache: true})
+		is.NoErr(err) // should not error
+
+		b, err := os.ReadFile(result.Path)
+		is.NoErr(err)                       // should keep the download
+		is.Equal(string(b), "file content") // should write the content
+	})
+}
+
+func TestDownloadTool_cacheEviction(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+	t.Setenv("RUNNER_TEMP", t.TempDir())
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "content of %s", r.URL.Path)
+	}))
+	defer ts.Close()
+
+	results := []*DownloadResult{}
+	for _, p := range []string{"/one", "/two", "/three"} {
+		u, _ := url.Parse(ts.URL + p)
+		result, err := DownloadTool(t.Context(), nil, *u, DownloadOptions{Cache: true, CacheMaxSize: 35})
+		is.NoErr(err) // should not error
+
+		results = append(results, result)
+	}
+
+	cacheDir, _ := getDownloadCacheDirectory()
+	objects, err := listDownloadCacheObjects(cacheDir)
+	is.NoErr(err)             // should not error
+	is.Equal(len(objects), 2) // should evict to stay under the maximum size
+
+	_, err = os.Stat(getDownloadCacheObjectPath(cacheDir, results[0].SHA256))
+	is.True(os.IsNotExist(err)) // should evict the least recently used file
+
+	entry, err := readDownloadCacheEntry(cacheDir, getChecksumCacheKey(Checksum{Algorithm: SHA256, Digest: results[0].SHA256}))
+	is.NoErr(err)         // should not error
+	is.True(entry == nil) // should remove the index entries of evicted files
+}
+
+func TestClearDownloadCache(t *testing.T) {
+	t.Run("clears_cached_downloads", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+		t.Setenv("RUNNER_TEMP", t.TempDir())
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "file content")
+		}))
+		defer ts.Close()
+
+		u, _ := url.Parse(ts.URL + "/tool")
+		_, err := DownloadTool(t.Context(), nil, *u, DownloadOptions{Cache: true})
+		is.NoErr(err) // should not error
+
+		count, size, err := ClearDownloadCache()
+
+		is.NoErr(err)                              // should not error
+		is.Equal(count, 1)                         // should remove the cached download
+		is.Equal(size, int64(len("file content"))) // should return the size removed
+
+		cacheDir, _ := getDownloadCacheDirectory()
+		_, err = os.Stat(cacheDir)
+		is.True(os.IsNotExist(err)) // should remove the download cache
+	})
+
+	t.Run("succeeds_without_download_cache", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("RUNNER_TOOL_CACHE", t.TempDir())
+
+		count, size, err := ClearDownloadCache()
+
+		is.NoErr(err)            // should not error
+		is.Equal(count, 0)       // should not remove anything
+		is.Equal(size, int64(0)) // should not free anything
+	})
+}
@@ -0,0 +1,222 @@
+package toolcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/action-stars/ghactl/internal/fileio"
+)
+
+// CacheEntry is a single tool, version and architecture directory in the GitHub Actions runner tool cache.
+// Arch is the architecture directory name, which is qualified with the OS for tools cached for another OS,
+// and OS is that OS, or empty for tools cached for the host OS.
+type CacheEntry struct {
+	Tool     string
+	Version  string
+	Arch     string
+	OS       string
+	Path     string
+	Complete bool
+	ModTime  time.Time
+}
+
+// ListCacheEntries returns all entries in the GitHub Actions runner tool cache.
+// If tool is not empty, only the entries for that tool are returned.
+// The ModTime of a complete entry is the time its marker was written.
+func ListCacheEntries(tool string) ([]CacheEntry, error) {
+	d, err := GetToolCacheDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	tools := []string{tool}
+	if tool == "" {
+		tools, err = listDirNames(d)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entries := []CacheEntry{}
+
+	for _, t := range tools {
+		toolPath := filepath.Join(d, t)
+		exists, err := fileio.DirExists(toolPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			continue
+		}
+
+		versions, err := listDirNames(toolPath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range versions {
+			archs, err := listDirNames(filepath.Join(toolPath, v))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, a := range archs {
+				entry, err := newCacheEntry(t, v, a, filepath.Join(toolPath, v, a))
+				if err != nil {
+					return nil, err
+				}
+
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// newCacheEntry returns the cache entry for an existing tool path.
+func newCacheEntry(tool, version, arch, toolPath string) (CacheEntry, error) {
+	osName, _ := splitPlatformArch(arch)
+
+	entry := CacheEntry{
+		Tool:    tool,
+		Version: version,
+		Arch:    arch,
+		OS:      osName,
+		Path:    toolPath,
+	}
+
+	fi, err := os.Stat(getMarkerPath(toolPath))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return CacheEntry{}, err
+	}
+
+	if err == nil {
+		entry.Complete = true
+		entry.ModTime = fi.ModTime()
+		return entry, nil
+	}
+
+	fi, err = os.Stat(toolPath)
+	if err != nil {
+		return CacheEntry{}, err
+	}
+
+	entry.ModTime = fi.ModTime()
+	return entry, nil
+}
+
+// lockCacheEntry acquires the tool lock of a cache entry, and returns the entry read again under the lock, as it may
+// have been completed or removed while waiting for the lock. The entry is nil if it no longer exists.
+// The lock must be released with Close.
+func lockCacheEntry(ctx context.Context, e CacheEntry) (*ToolLock, *CacheEntry, error) {
+	lock, err := LockTool(ctx, e.Tool, e.Version, e.Arch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, err := newCacheEntry(e.Tool, e.Version, e.Arch, e.Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return lock, nil, nil
+		}
+		return nil, nil, errors.Join(err, lock.Close())
+	}
+
+	return lock, &current, nil
+}
+
+// removeCacheEntry removes a tool path from the GitHub Actions runner tool cache.
+// The marker is removed first so that the tool is never found in a partially removed state,
+// then its sidecars and directory, and finally any version and tool directories left empty.
+func removeCacheEntry(toolPath string) error {
+	if err := os.Remove(getMarkerPath(toolPath)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	for _, p := range []string{getMetadataPath(toolPath), getHashTreePath(toolPath)} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	if err := os.RemoveAll(toolPath); err != nil {
+		return err
+	}
+
+	versionPath := filepath.Dir(toolPath)
+	if err := removeEmptyDir(versionPath); err != nil {
+		return err
+	}
+
+	return removeEmptyDir(filepath.Dir(versionPath))
+}
+
+// removeEmptyDir removes a directory if it exists and has no entries.
+func removeEmptyDir(p string) error {
+	items, err := os.ReadDir(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if len(items) > 0 {
+		return nil
+	}
+
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// listDirNames returns the sorted names of the directories in a directory.
+func listDirNames(p string) ([]string, error) {
+	items, err := os.ReadDir(p)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", p, err)
+	}
+
+	names := []string{}
+	for _, item := range items {
+		if item.IsDir() && !strings.HasPrefix(item.Name(), ".") {
+			names = append(names, item.Name())
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// getEntrySize returns the size of a tool path and its marker and sidecars.
+func getEntrySize(toolPath string) (int64, error) {
+	size, err := fileio.DirSize(toolPath)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, sidecar := range []func(string) string{getMarkerPath, getMetadataPath, getHashTreePath} {
+		fi, err := os.Stat(sidecar(toolPath))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return 0, err
+		}
+
+		size += fi.Size()
+	}
+
+	return size, nil
+}
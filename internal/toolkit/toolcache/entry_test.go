@@ -0,0 +1,105 @@
+package toolcache
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/action-stars/ghactl/internal/fileio"
+)
+
+func TestListCacheEntries(t *testing.T) {
+	t.Run("errors_if_the_tool_cache_dir_does_not_exist", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv(runnerToolCacheLookup, filepath.Join(t.TempDir(), "non-existent"))
+
+		_, err := ListCacheEntries("")
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("returns_an_empty_list_if_tool_is_not_cached", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv(runnerToolCacheLookup, t.TempDir())
+
+		entries, err := ListCacheEntries("tool")
+
+		is.NoErr(err)             // should not error
+		is.Equal(len(entries), 0) // should be empty
+	})
+
+	t.Run("returns_all_entries", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, tc)
+
+		mustCreateTestCacheEntry(t, tc, "tool-a", "1.0.0", "x64", true)
+		mustCreateTestCacheEntry(t, tc, "tool-a", "1.1.0", "x64", false)
+		mustCreateTestCacheEntry(t, tc, "tool-b", "2.0.0", "arm64", true)
+
+		entries, err := ListCacheEntries("")
+
+		is.NoErr(err)             // should not error
+		is.Equal(len(entries), 3) // should return all entries
+
+		is.Equal(entries[0].Tool, "tool-a")   // should match tool
+		is.Equal(entries[0].Version, "1.0.0") // should match version
+		is.Equal(entries[0].Arch, "x64")      // should match arch
+		is.True(entries[0].Complete)          // should be complete
+		is.True(!entries[1].Complete)         // should be incomplete
+		is.Equal(entries[2].Arch, "arm64")    // should match arch
+	})
+
+	t.Run("returns_entries_for_a_tool", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, tc)
+
+		mustCreateTestCacheEntry(t, tc, "tool-a", "1.0.0", "x64", true)
+		mustCreateTestCacheEntry(t, tc, "tool-b", "2.0.0", "x64", true)
+
+		entries, err := ListCacheEntries("tool-b")
+
+		is.NoErr(err)                       // should not error
+		is.Equal(len(entries), 1)           // should return tool entries
+		is.Equal(entries[0].Tool, "tool-b") // should match tool
+	})
+}
+
+func Test_removeCacheEntry(t *testing.T) {
+	t.Run("removes_marker_and_empty_parent_directories", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+
+		p := mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "x64", true)
+
+		err := removeCacheEntry(p)
+
+		is.NoErr(err) // should not error
+
+		markerExists, _ := fileio.FileExists(getMarkerPath(p))
+		toolExists, _ := fileio.DirExists(filepath.Join(tc, "tool"))
+
+		is.True(!markerExists) // marker should be removed
+		is.True(!toolExists)   // tool dir should be removed
+	})
+
+	t.Run("keeps_non_empty_parent_directories", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+
+		p := mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "x64", true)
+		mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "arm64", true)
+
+		err := removeCacheEntry(p)
+
+		is.NoErr(err) // should not error
+
+		entryExists, _ := fileio.DirExists(p)
+		versionExists, _ := fileio.DirExists(filepath.Join(tc, "tool", "1.0.0"))
+
+		is.True(!entryExists)  // entry should be removed
+		is.True(versionExists) // version dir should be kept
+	})
+}
@@ -10,10 +10,13 @@ import (
 	"github.com/action-stars/ghactl/internal/toolkit/core"
 )
 
+// extractDirPrefix is the name prefix of the temporary directories archives are extracted into.
+const extractDirPrefix = "extract-"
+
 // ExtractTar extracts a tarball into a temporary directory.
 // If gz is true, it will decompress the file.
 func ExtractTar(tarFile string, gz bool) (string, error) {
-	dest, err := core.CreateTempDir()
+	dest, err := createExtractDir()
 	if err != nil {
 		return "", err
 	}
@@ -26,7 +29,7 @@ func ExtractTar(tarFile string, gz bool) (string, error) {
 
 // ExtractZip extracts a zip archive into a temporary directory.
 func ExtractZip(zipFile string) (string, error) {
-	dest, err := core.CreateTempDir()
+	dest, err := createExtractDir()
 	if err != nil {
 		return "", err
 	}
@@ -37,6 +40,16 @@ func ExtractZip(zipFile string) (string, error) {
 	return dest, nil
 }
 
+// createExtractDir creates a temporary directory to extract an archive into.
+func createExtractDir() (string, error) {
+	d, err := core.GetTempDir()
+	if err != nil {
+		return "", err
+	}
+
+	return os.MkdirTemp(d, extractDirPrefix+"*")
+}
+
 // ResolveToolDirectory navigates nested directories to find the actual tool location.
 // It handles:
 //   - Single nested directories
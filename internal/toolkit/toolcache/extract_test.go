@@ -18,7 +18,7 @@ func TestExtractTar(t *testing.T) {
 		wantErr   bool
 	}{
 		{
-			name:      "errors_if_temp_dir_is_not_defined",
+			name:      "errors_if_no_temp_dir_is_found",
 			tempUnset: true,
 			file:      "../../../testdata/file-and-dir.tar",
 			wantErr:   true,
@@ -44,14 +44,17 @@ func TestExtractTar(t *testing.T) {
 			is := is.New(t)
 
 			temp := ""
+			osTemp := t.TempDir()
 			if tt.tempUnset {
 				temp = ""
+				osTemp = filepath.Join(osTemp, "non-existent")
 			} else {
 				temp = t.TempDir()
 			}
 			t.Setenv("RUNNER_TEMP", temp)
 			t.Setenv("TEMPDIR", "")
 			t.Setenv("TMP", "")
+			t.Setenv("TMPDIR", osTemp)
 
 			p, err := ExtractTar(tt.file, tt.gz)
 
@@ -76,7 +79,7 @@ func TestExtractZip(t *testing.T) {
 		wantErr   bool
 	}{
 		{
-			name:      "errors_if_temp_dir_is_not_defined",
+			name:      "errors_if_no_temp_dir_is_found",
 			tempUnset: true,
 			file:      "../../../testdata/file-and-dir.zip",
 			wantErr:   true,
@@ -97,14 +100,17 @@ func TestExtractZip(t *testing.T) {
 			is := is.New(t)
 
 			temp := ""
+			osTemp := t.TempDir()
 			if tt.tempUnset {
 				temp = ""
+				osTemp = filepath.Join(osTemp, "non-existent")
 			} else {
 				temp = t.TempDir()
 			}
 			t.Setenv("RUNNER_TEMP", temp)
 			t.Setenv("TEMPDIR", "")
 			t.Setenv("TMP", "")
+			t.Setenv("TMPDIR", osTemp)
 
 			p, err := ExtractZip(tt.file)
 
@@ -1,10 +1,22 @@
 package toolcache
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 )
 
+// otherOS returns an OS that isn't the host OS.
+func otherOS() string {
+	if runtime.GOOS == "darwin" {
+		return "linux"
+	}
+	return "darwin"
+}
+
 func mustCreateTestDir(t *testing.T, path string) {
 	t.Helper()
 	if err := os.MkdirAll(path, 0o755); err != nil {
@@ -18,3 +30,81 @@ func mustCreateTestFile(t *testing.T, path, content string) {
 		t.Fatal(err)
 	}
 }
+
+func mustCreateTestCacheEntry(t *testing.T, tc, tool, version, arch string, complete bool) string {
+	t.Helper()
+
+	p := filepath.Join(tc, tool, version, arch)
+	mustCreateTestDir(t, p)
+	mustCreateTestFile(t, filepath.Join(p, tool), "binary")
+
+	if complete {
+		mustCreateTestFile(t, getMarkerPath(p), "")
+	}
+
+	return p
+}
+
+func readTestBundleNames(t *testing.T, bundlePath string) map[string]bool {
+	t.Helper()
+
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gzr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gzr.Close()
+
+	names := map[string]bool{}
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names[header.Name] = true
+	}
+
+	return names
+}
+
+func writeTestBundle(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	bundlePath := filepath.Join(t.TempDir(), "bundle.tar.gz")
+
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gzw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gzw)
+
+	for name, content := range files {
+		header := &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return bundlePath
+}
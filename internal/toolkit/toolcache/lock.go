@@ -0,0 +1,78 @@
+package toolcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockPollInterval is the interval between attempts to acquire a tool lock held by another process.
+const lockPollInterval = 100 * time.Millisecond
+
+// ToolLock is an exclusive lock on a tool version and architecture in the GitHub Actions runner tool cache.
+// It is held across processes, so concurrent jobs on the same runner don't install the same tool at the same time.
+type ToolLock struct {
+	f *os.File
+}
+
+// LockTool acquires the lock for a tool version and architecture, waiting until it is released by any other holder
+// or the context is done. The lock must be released with Close.
+func LockTool(ctx context.Context, tool, version, arch string) (*ToolLock, error) {
+	cacheDir, err := GetToolCacheDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	if tool == "" {
+		return nil, fmt.Errorf("tool is not defined")
+	}
+
+	if version == "" {
+		return nil, fmt.Errorf("version is not defined")
+	}
+
+	if arch == "" {
+		return nil, fmt.Errorf("arch is not defined")
+	}
+
+	lockPath := getLockPath(cacheDir, tool, version, PlatformArch("", arch))
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0o755); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			return nil, errors.Join(err, f.Close())
+		}
+
+		if locked {
+			return &ToolLock{f: f}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Join(ctx.Err(), f.Close())
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// Close releases the lock.
+func (l *ToolLock) Close() error {
+	return errors.Join(unlockFile(l.f), l.f.Close())
+}
+
+// getLockPath returns the path to the lock file for a specific version and architecture of a tool.
+// Lock files are kept in a hidden directory so they are never mistaken for cached tools.
+func getLockPath(cacheDir, tool, version, arch string) string {
+	return filepath.Join(cacheDir, ".locks", tool, version, arch+".lock")
+}
@@ -0,0 +1,69 @@
+package toolcache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestLockTool(t *testing.T) {
+	t.Run("errors_if_the_tool_cache_dir_does_not_exist", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv(runnerToolCacheLookup, filepath.Join(t.TempDir(), "non-existent"))
+
+		_, err := LockTool(t.Context(), "tool", "1.0.0", "amd64")
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("errors_if_tool_is_not_defined", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv(runnerToolCacheLookup, t.TempDir())
+
+		_, err := LockTool(t.Context(), "", "1.0.0", "amd64")
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("waits_for_the_lock_to_be_released", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv(runnerToolCacheLookup, t.TempDir())
+
+		first, err := LockTool(t.Context(), "tool", "1.0.0", "amd64")
+		is.NoErr(err) // should not error
+
+		ctx, cancel := context.WithTimeout(t.Context(), 3*lockPollInterval)
+		defer cancel()
+
+		_, err = LockTool(ctx, "tool", "1.0.0", "amd64")
+		is.True(err != nil) // should time out while the lock is held
+
+		go func() {
+			time.Sleep(lockPollInterval)
+			_ = first.Close()
+		}()
+
+		second, err := LockTool(t.Context(), "tool", "1.0.0", "amd64")
+		is.NoErr(err)            // should acquire the released lock
+		is.NoErr(second.Close()) // should release
+	})
+
+	t.Run("does_not_block_other_versions", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv(runnerToolCacheLookup, t.TempDir())
+
+		first, err := LockTool(t.Context(), "tool", "1.0.0", "amd64")
+		is.NoErr(err) // should not error
+		defer first.Close()
+
+		ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+		defer cancel()
+
+		second, err := LockTool(ctx, "tool", "2.0.0", "amd64")
+		is.NoErr(err)            // should not wait
+		is.NoErr(second.Close()) // should release
+	})
+}
@@ -0,0 +1,52 @@
+package toolcache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DownloadManifestEntry is a file to download in a download manifest.
+type DownloadManifestEntry struct {
+	// URL is the URL to download the file from.
+	URL string `json:"url"`
+	// SHA256 is the expected SHA-256 digest of the file, or empty to not verify it.
+	SHA256 string `json:"sha256,omitempty"`
+	// Dest is the directory or file path to save the file to, or empty for a temporary directory.
+	Dest string `json:"dest,omitempty"`
+}
+
+// ParseDownloadManifest parses a download manifest, a JSON array of entries.
+func ParseDownloadManifest(data []byte) ([]DownloadManifestEntry, error) {
+	entries := []DownloadManifestEntry{}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&entries); err != nil {
+		return nil, fmt.Errorf("parsing download manifest: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("download manifest has no entries")
+	}
+
+	for i, e := range entries {
+		if strings.TrimSpace(e.URL) == "" {
+			return nil, fmt.Errorf("download manifest entry %d has no url", i+1)
+		}
+	}
+
+	return entries, nil
+}
+
+// ReadDownloadManifest reads a download manifest from a file.
+func ReadDownloadManifest(p string) ([]DownloadManifestEntry, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseDownloadManifest(data)
+}
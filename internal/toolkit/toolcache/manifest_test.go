@@ -0,0 +1,83 @@
+package toolcache
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseDownloadManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []DownloadManifestEntry
+		wantErr bool
+	}{
+		{
+			name: "parses_entries",
+			data: `[{"url": "https://example.com/tool-a.tar.gz", "sha256": "abc", "dest": "bin/"}, {"url": "https://example.com/tool-b"}]`,
+			want: []DownloadManifestEntry{
+				{URL: "https://example.com/tool-a.tar.gz", SHA256: "abc", Dest: "bin/"},
+				{URL: "https://example.com/tool-b"},
+			},
+		},
+		{
+			name:    "errors_on_invalid_json",
+			data:    `{"url": "https://example.com/tool"}`,
+			wantErr: true,
+		},
+		{
+			name:    "errors_on_unknown_fields",
+			data:    `[{"url": "https://example.com/tool", "sha265": "abc"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "errors_if_manifest_has_no_entries",
+			data:    `[]`,
+			wantErr: true,
+		},
+		{
+			name:    "errors_if_entry_has_no_url",
+			data:    `[{"url": "https://example.com/tool"}, {"sha256": "abc"}]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			got, err := ParseDownloadManifest([]byte(tt.data))
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err)          // should not error
+			is.Equal(got, tt.want) // should return the entries
+		})
+	}
+}
+
+func TestReadDownloadManifest(t *testing.T) {
+	t.Run("reads_manifest_file", func(t *testing.T) {
+		is := is.New(t)
+		p := filepath.Join(t.TempDir(), "downloads.json")
+		mustCreateTestFile(t, p, `[{"url": "https://example.com/tool"}]`)
+
+		got, err := ReadDownloadManifest(p)
+
+		is.NoErr(err)                                                             // should not error
+		is.Equal(got, []DownloadManifestEntry{{URL: "https://example.com/tool"}}) // should return the entries
+	})
+
+	t.Run("errors_if_file_does_not_exist", func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := ReadDownloadManifest(filepath.Join(t.TempDir(), "missing.json"))
+
+		is.True(err != nil) // should error
+	})
+}
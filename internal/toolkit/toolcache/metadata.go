@@ -0,0 +1,105 @@
+package toolcache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+	"time"
+)
+
+// Metadata describes where a tool in the GitHub Actions runner tool cache came from.
+// It is written as a JSON sidecar next to the tool's marker.
+type Metadata struct {
+	Tool          string         `json:"tool"`
+	Version       string         `json:"version"`
+	OS            string         `json:"os"`
+	Arch          string         `json:"arch"`
+	Source        MetadataSource `json:"source"`
+	SHA256        string         `json:"sha256,omitempty"`
+	InstalledAt   time.Time      `json:"installedAt"`
+	GhactlVersion string         `json:"ghactlVersion,omitempty"`
+}
+
+// MetadataSource is the origin of a cached tool.
+// Owner, Repo and Asset are set for GitHub release assets, URL for downloads and Path for local sources.
+type MetadataSource struct {
+	Owner string `json:"owner,omitempty"`
+	Repo  string `json:"repo,omitempty"`
+	Asset string `json:"asset,omitempty"`
+	URL   string `json:"url,omitempty"`
+	Path  string `json:"path,omitempty"`
+}
+
+// GetMetadata returns the metadata of a tool in the GitHub Actions runner tool cache matching the version constraint,
+// found with the find options. It returns an error if the tool is not cached or was cached without metadata.
+func GetMetadata(tool, arch, versionSpec string, options FindOptions) (*Metadata, error) {
+	toolPath, err := FindTool(tool, arch, versionSpec, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if toolPath == "" {
+		return nil, fmt.Errorf("tool %s %s is not cached", tool, versionSpec)
+	}
+
+	metadata, err := readMetadata(toolPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("tool %s %s was cached without metadata", tool, versionSpec)
+		}
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+// readMetadata reads the metadata sidecar for a tool path.
+func readMetadata(toolPath string) (*Metadata, error) {
+	data, err := os.ReadFile(getMetadataPath(toolPath))
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &Metadata{}
+	if err := json.Unmarshal(data, metadata); err != nil {
+		return nil, fmt.Errorf("parsing metadata: %w", err)
+	}
+
+	return metadata, nil
+}
+
+// completeMetadata fills in the metadata fields that are known when a tool is cached.
+func completeMetadata(metadata Metadata, tool, version, arch string) Metadata {
+	metadata.Tool = tool
+	metadata.Version = version
+	metadata.Arch = arch
+
+	if metadata.OS == "" {
+		metadata.OS = runtime.GOOS
+	}
+
+	if metadata.InstalledAt.IsZero() {
+		metadata.InstalledAt = time.Now().UTC()
+	}
+
+	return metadata
+}
+
+// writeMetadata writes the metadata sidecar for a tool path.
+func writeMetadata(toolPath string, metadata Metadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(getMetadataPath(toolPath), append(data, '\n'), 0o644)
+}
+
+// getMetadataPath returns the path to the metadata sidecar for a specific version and architecture of a tool
+// in the GitHub Actions runner tool cache.
+func getMetadataPath(toolPath string) string {
+	return fmt.Sprintf("%s.metadata.json", toolPath)
+}
@@ -0,0 +1,79 @@
+package toolcache
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestGetMetadata(t *testing.T) {
+	t.Run("errors_if_tool_is_not_cached", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv(runnerToolCacheLookup, t.TempDir())
+
+		_, err := GetMetadata("tool", "amd64", "1.0.0", FindOptions{})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("errors_if_tool_was_cached_without_metadata", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, tc)
+		mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "x64", true)
+
+		_, err := GetMetadata("tool", "amd64", "1.0.0", FindOptions{})
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("returns_metadata_written_by_cache_file", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv(runnerToolCacheLookup, t.TempDir())
+
+		source := filepath.Join(t.TempDir(), "tool")
+		mustCreateTestFile(t, source, "src")
+
+		_, err := CacheFile(source, "tool", "tool", "1.0.0", "amd64", Metadata{
+			Source:        MetadataSource{URL: "https://example.com/tool"},
+			GhactlVersion: "1.2.3",
+		})
+		is.NoErr(err) // should not error
+
+		metadata, err := GetMetadata("tool", "amd64", "^1.0.0", FindOptions{})
+
+		is.NoErr(err)                                                                                 // should not error
+		is.Equal(metadata.Tool, "tool")                                                               // tool should match
+		is.Equal(metadata.Version, "1.0.0")                                                           // version should match
+		is.Equal(metadata.Arch, "x64")                                                                // arch should match
+		is.Equal(metadata.OS, runtime.GOOS)                                                           // os should default to the host
+		is.Equal(metadata.Source.URL, "https://example.com/tool")                                     // source should match
+		is.Equal(metadata.SHA256, "25a6634263c1b1f6fc4697a04e2b9904ea4b042a89af59dc93ec1f5d44848a26") // digest should be computed
+		is.Equal(metadata.GhactlVersion, "1.2.3")                                                     // ghactl version should match
+		is.True(!metadata.InstalledAt.IsZero())                                                       // install time should be set
+	})
+
+	t.Run("records_source_path_for_cache_dir", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv(runnerToolCacheLookup, t.TempDir())
+
+		source := t.TempDir()
+		mustCreateTestFile(t, filepath.Join(source, "tool"), "src")
+
+		_, err := CacheDir(source, "tool", "1.0.0", "amd64", Metadata{OS: "darwin"}, CacheDirOptions{})
+		is.NoErr(err) // should not error
+
+		metadata, err := GetMetadata("tool", PlatformArch("darwin", "amd64"), "1.0.0", FindOptions{})
+
+		is.NoErr(err)                          // should not error
+		is.Equal(metadata.Source.Path, source) // source path should be recorded
+		is.Equal(metadata.OS, "darwin")        // os should match
+
+		metadata, err = GetMetadata("tool", "amd64", "1.0.0", FindOptions{OS: "darwin"})
+
+		is.NoErr(err)                   // should not error
+		is.Equal(metadata.OS, "darwin") // should find the tool for the os in the find options
+	})
+}
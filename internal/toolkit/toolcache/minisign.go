@@ -0,0 +1,154 @@
+package toolcache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// minisignAlgorithm is the algorithm of minisign keys and legacy signatures of the whole file.
+	minisignAlgorithm = "Ed"
+	// minisignHashedAlgorithm is the algorithm of minisign signatures of the BLAKE2b-512 hash of the file.
+	minisignHashedAlgorithm = "ED"
+	// minisignKeyIDSize is the size of minisign key IDs.
+	minisignKeyIDSize = 8
+	// minisignTrustedComment is the prefix of the trusted comment line of a minisign signature.
+	minisignTrustedComment = "trusted comment: "
+)
+
+// minisignPublicKey is a minisign Ed25519 public key.
+type minisignPublicKey struct {
+	KeyID [minisignKeyIDSize]byte
+	Key   ed25519.PublicKey
+}
+
+// verifyMinisign verifies the file at a path against a minisign signature and public key.
+// The trusted comment is verified with the global signature.
+func verifyMinisign(p string, signature, publicKey []byte) error {
+	key, err := parseMinisignPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	lines := getMinisignLines(signature)
+	if len(lines) < 3 {
+		return fmt.Errorf("signature is incomplete")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(sig) != 2+minisignKeyIDSize+ed25519.SignatureSize {
+		return fmt.Errorf("signature is invalid")
+	}
+
+	algorithm := string(sig[:2])
+	if algorithm != minisignAlgorithm && algorithm != minisignHashedAlgorithm {
+		return fmt.Errorf("signature algorithm %q is not supported", algorithm)
+	}
+
+	if !bytes.Equal(sig[2:2+minisignKeyIDSize], key.KeyID[:]) {
+		return fmt.Errorf("signature key ID %X doesn't match public key ID %X", reverseBytes(sig[2:2+minisignKeyIDSize]), reverseBytes(key.KeyID[:]))
+	}
+
+	trustedComment, ok := strings.CutPrefix(lines[1], minisignTrustedComment)
+	if !ok {
+		return fmt.Errorf("signature has no trusted comment")
+	}
+
+	globalSig, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("signature has an invalid global signature")
+	}
+
+	message, err := readMinisignMessage(p, algorithm == minisignHashedAlgorithm)
+	if err != nil {
+		return err
+	}
+
+	fileSig := sig[2+minisignKeyIDSize:]
+	if !ed25519.Verify(key.Key, message, fileSig) {
+		return fmt.Errorf("signature doesn't match the file")
+	}
+
+	// The global signature signs the file signature followed by the trusted comment.
+	if !ed25519.Verify(key.Key, append(bytes.Clone(fileSig), trustedComment...), globalSig) {
+		return fmt.Errorf("trusted comment signature is invalid")
+	}
+
+	return nil
+}
+
+// parseMinisignPublicKey parses a minisign public key file, or just its base64 encoded key.
+func parseMinisignPublicKey(data []byte) (*minisignPublicKey, error) {
+	lines := getMinisignLines(data)
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("public key is empty")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(raw) != 2+minisignKeyIDSize+ed25519.PublicKeySize || string(raw[:2]) != minisignAlgorithm {
+		return nil, fmt.Errorf("public key is not a minisign public key")
+	}
+
+	key := &minisignPublicKey{Key: ed25519.PublicKey(raw[2+minisignKeyIDSize:])}
+	copy(key.KeyID[:], raw[2:2+minisignKeyIDSize])
+
+	return key, nil
+}
+
+// getMinisignLines returns the non-empty lines of a minisign file, without the untrusted comment.
+func getMinisignLines(data []byte) []string {
+	lines := []string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// readMinisignMessage returns the message signed by a minisign signature of a file: the BLAKE2b-512 hash of the file
+// if it's hashed, or otherwise the file itself.
+func readMinisignMessage(p string, hashed bool) ([]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if !hashed {
+		return io.ReadAll(f)
+	}
+
+	h, err := blake2b.New512(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+// reverseBytes returns a reversed copy of b, to format little-endian minisign key IDs as minisign does.
+func reverseBytes(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
@@ -0,0 +1,76 @@
+package toolcache
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+// mirrorsLookup is the environment variable with the download mirror rewrite table.
+const mirrorsLookup = "GHACTL_MIRRORS"
+
+// Mirror rewrites download URLs starting with a prefix to a mirror.
+type Mirror struct {
+	// Prefix is the start of the URLs that are downloaded from the mirror, such as https://github.com/.
+	Prefix string
+	// Replacement replaces the prefix, such as https://artifactory.example.com/github/.
+	Replacement string
+}
+
+// ParseMirrors parses a mirror rewrite table of prefix=replacement entries, separated by commas or new lines.
+func ParseMirrors(s string) ([]Mirror, error) {
+	mirrors := []Mirror{}
+
+	for _, entry := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		prefix, replacement, ok := strings.Cut(entry, "=")
+		prefix, replacement = strings.TrimSpace(prefix), strings.TrimSpace(replacement)
+		if !ok || prefix == "" || replacement == "" {
+			return nil, fmt.Errorf("mirror %q is not in prefix=replacement format", entry)
+		}
+
+		if u, err := url.Parse(replacement); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("mirror replacement %s is not an absolute URL", replacement)
+		}
+
+		mirrors = append(mirrors, Mirror{Prefix: prefix, Replacement: replacement})
+	}
+
+	return mirrors, nil
+}
+
+// LookupMirrors returns the mirror rewrite table from the GHACTL_MIRRORS environment variable.
+func LookupMirrors() ([]Mirror, error) {
+	mirrors, err := ParseMirrors(os.Getenv(mirrorsLookup))
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", mirrorsLookup, err)
+	}
+
+	return mirrors, nil
+}
+
+// GetMirrorURLs returns the URLs to try in order to download from a URL: the URL rewritten by each mirror with a
+// matching prefix, in table order, followed by the URL itself.
+func GetMirrorURLs(rawURL string, mirrors []Mirror) []string {
+	urls := []string{}
+
+	for _, m := range mirrors {
+		if rest, ok := strings.CutPrefix(rawURL, m.Prefix); ok {
+			if u := m.Replacement + rest; !slices.Contains(urls, u) {
+				urls = append(urls, u)
+			}
+		}
+	}
+
+	if !slices.Contains(urls, rawURL) {
+		urls = append(urls, rawURL)
+	}
+
+	return urls
+}
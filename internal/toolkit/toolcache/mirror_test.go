@@ -0,0 +1,110 @@
+package toolcache
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseMirrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []Mirror
+		wantErr bool
+	}{
+		{
+			name: "returns_no_mirrors_for_empty_table",
+			s:    "",
+			want: []Mirror{},
+		},
+		{
+			name: "parses_comma_separated_mirrors",
+			s:    "https://github.com/=https://artifactory.example.com/github/, https://dl.google.com/=https://mirror.example.com/google/",
+			want: []Mirror{
+				{Prefix: "https://github.com/", Replacement: "https://artifactory.example.com/github/"},
+				{Prefix: "https://dl.google.com/", Replacement: "https://mirror.example.com/google/"},
+			},
+		},
+		{
+			name: "parses_line_separated_mirrors",
+			s:    "https://github.com/=https://artifactory.example.com/github/\n\nhttps://github.com/=https://nexus.example.com/github/\n",
+			want: []Mirror{
+				{Prefix: "https://github.com/", Replacement: "https://artifactory.example.com/github/"},
+				{Prefix: "https://github.com/", Replacement: "https://nexus.example.com/github/"},
+			},
+		},
+		{
+			name:    "errors_without_separator",
+			s:       "https://github.com/",
+			wantErr: true,
+		},
+		{
+			name:    "errors_on_relative_replacement",
+			s:       "https://github.com/=/github/",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			got, err := ParseMirrors(tt.s)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err)          // should not error
+			is.Equal(got, tt.want) // should match expected
+		})
+	}
+}
+
+func TestLookupMirrors(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("GHACTL_MIRRORS", "https://github.com/")
+
+	_, err := LookupMirrors()
+
+	is.True(err != nil) // should error on an invalid table
+}
+
+func TestGetMirrorURLs(t *testing.T) {
+	mirrors := []Mirror{
+		{Prefix: "https://github.com/", Replacement: "https://artifactory.example.com/github/"},
+		{Prefix: "https://dl.google.com/", Replacement: "https://mirror.example.com/google/"},
+		{Prefix: "https://github.com/cli/", Replacement: "https://nexus.example.com/cli/"},
+	}
+
+	tests := []struct {
+		name   string
+		rawURL string
+		want   []string
+	}{
+		{
+			name:   "tries_matching_mirrors_before_url",
+			rawURL: "https://github.com/cli/cli/releases/download/v2.94.0/gh_2.94.0_linux_amd64.tar.gz",
+			want: []string{
+				"https://artifactory.example.com/github/cli/cli/releases/download/v2.94.0/gh_2.94.0_linux_amd64.tar.gz",
+				"https://nexus.example.com/cli/cli/releases/download/v2.94.0/gh_2.94.0_linux_amd64.tar.gz",
+				"https://github.com/cli/cli/releases/download/v2.94.0/gh_2.94.0_linux_amd64.tar.gz",
+			},
+		},
+		{
+			name:   "returns_url_without_matching_mirror",
+			rawURL: "https://example.com/tool.tar.gz",
+			want:   []string{"https://example.com/tool.tar.gz"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			is.Equal(GetMirrorURLs(tt.rawURL, mirrors), tt.want) // should match expected
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package toolcache
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// netrcLookup is the environment variable used to find the netrc file.
+const netrcLookup = "NETRC"
+
+// netrcCredentials are the login and password for a machine in a netrc file.
+type netrcCredentials struct {
+	Login    string
+	Password string
+}
+
+// getNetrcPath returns the path to the netrc file, from NETRC or in the home directory.
+func getNetrcPath() (string, error) {
+	if p := os.Getenv(netrcLookup); p != "" {
+		return p, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	name := ".netrc"
+	if runtime.GOOS == "windows" {
+		name = "_netrc"
+	}
+
+	return filepath.Join(home, name), nil
+}
+
+// readNetrc returns the credentials for a host from the netrc file, or nil if it has no credentials for the host.
+func readNetrc(host string) (*netrcCredentials, error) {
+	p, err := getNetrcPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("reading netrc file: %w", err)
+	}
+
+	return parseNetrc(data, host), nil
+}
+
+// parseNetrc returns the credentials for a host from the contents of a netrc file, falling back to the default entry.
+// It returns nil if there are no credentials for the host.
+func parseNetrc(data []byte, host string) *netrcCredentials {
+	var (
+		current  *netrcCredentials
+		match    *netrcCredentials
+		fallback *netrcCredentials
+		inMacro  bool
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Macro definitions run until the next empty line.
+		if inMacro {
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			switch fields[i] {
+			case "machine":
+				current = nil
+				if i+1 < len(fields) {
+					i++
+					if fields[i] == host && match == nil {
+						match = &netrcCredentials{}
+						current = match
+					}
+				}
+			case "default":
+				current = nil
+				if fallback == nil {
+					fallback = &netrcCredentials{}
+					current = fallback
+				}
+			case "login", "password", "account":
+				if i+1 >= len(fields) {
+					continue
+				}
+				i++
+				if current == nil {
+					continue
+				}
+				switch fields[i-1] {
+				case "login":
+					current.Login = fields[i]
+				case "password":
+					current.Password = fields[i]
+				}
+			case "macdef":
+				current = nil
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+
+	if match != nil {
+		return match
+	}
+
+	return fallback
+}
@@ -0,0 +1,87 @@
+package toolcache
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseNetrc(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		host string
+		want *netrcCredentials
+	}{
+		{
+			name: "returns_machine_credentials",
+			data: "machine example.com login user password secret\n",
+			host: "example.com",
+			want: &netrcCredentials{Login: "user", Password: "secret"},
+		},
+		{
+			name: "supports_multiline_entries",
+			data: "machine other.com\n  login other\n  password other\nmachine example.com\n  login user\n  account acct\n  password secret\n",
+			host: "example.com",
+			want: &netrcCredentials{Login: "user", Password: "secret"},
+		},
+		{
+			name: "falls_back_to_default",
+			data: "machine other.com login other password other\ndefault login anonymous password guest\n",
+			host: "example.com",
+			want: &netrcCredentials{Login: "anonymous", Password: "guest"},
+		},
+		{
+			name: "prefers_machine_over_default",
+			data: "default login anonymous password guest\nmachine example.com login user password secret\n",
+			host: "example.com",
+			want: &netrcCredentials{Login: "user", Password: "secret"},
+		},
+		{
+			name: "skips_macro_definitions",
+			data: "macdef init\nmachine example.com login macro password macro\n\nmachine example.com login user password secret\n",
+			host: "example.com",
+			want: &netrcCredentials{Login: "user", Password: "secret"},
+		},
+		{
+			name: "returns_nil_without_match",
+			data: "machine other.com login other password other\n",
+			host: "example.com",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			got := parseNetrc([]byte(tt.data), tt.host)
+
+			is.Equal(got, tt.want) // should match expected
+		})
+	}
+}
+
+func TestReadNetrc(t *testing.T) {
+	t.Run("reads_netrc_from_env", func(t *testing.T) {
+		is := is.New(t)
+		p := filepath.Join(t.TempDir(), ".netrc")
+		mustCreateTestFile(t, p, "machine example.com login user password secret\n")
+		t.Setenv("NETRC", p)
+
+		got, err := readNetrc("example.com")
+
+		is.NoErr(err)                                                       // should not error
+		is.Equal(got, &netrcCredentials{Login: "user", Password: "secret"}) // should match expected
+	})
+
+	t.Run("errors_if_netrc_does_not_exist", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("NETRC", filepath.Join(t.TempDir(), ".netrc"))
+
+		_, err := readNetrc("example.com")
+
+		is.True(err != nil) // should error
+	})
+}
@@ -0,0 +1,52 @@
+package toolcache
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+const (
+	// openPGPSignatureArmorStart is the first line of an armored OpenPGP signature.
+	openPGPSignatureArmorStart = "-----BEGIN PGP SIGNATURE-----"
+	// openPGPArmorStart is the start of the first line of any armored OpenPGP data.
+	openPGPArmorStart = "-----BEGIN PGP"
+)
+
+// verifyOpenPGPSignature verifies the file at a path against an armored or binary OpenPGP detached signature, signed
+// by a key in an armored or binary OpenPGP public key ring. RSA, DSA, ECDSA and EdDSA keys, such as the ed25519 keys
+// GnuPG creates by default, are supported.
+func verifyOpenPGPSignature(p string, signature, publicKey []byte) error {
+	var (
+		keyring openpgp.EntityList
+		err     error
+	)
+
+	if bytes.HasPrefix(bytes.TrimSpace(publicKey), []byte(openPGPArmorStart)) {
+		keyring, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(publicKey))
+	} else {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(publicKey))
+	}
+	if err != nil {
+		return fmt.Errorf("reading public key: %w", err)
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte(openPGPArmorStart)) {
+		_, err = openpgp.CheckArmoredDetachedSignature(keyring, f, bytes.NewReader(signature), nil)
+	} else {
+		_, err = openpgp.CheckDetachedSignature(keyring, f, bytes.NewReader(signature), nil)
+	}
+	if err != nil {
+		return fmt.Errorf("signature doesn't match the file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package toolcache
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// progressInterval is the minimum time between download progress lines.
+const progressInterval = 2 * time.Second
+
+// FormatBytes returns a human readable representation of a size in bytes.
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// downloadProgress writes progress lines for a download, at most once per progress interval.
+// A nil downloadProgress doesn't report anything.
+type downloadProgress struct {
+	w io.Writer
+	// total is the size of the download, or -1 if it's unknown.
+	total   int64
+	current int64
+	// reported is the number of bytes in the last progress line.
+	reported int64
+	start    time.Time
+	last     time.Time
+	now      func() time.Time
+}
+
+// progressReader is a reader that adds the bytes read to a download's progress.
+type progressReader struct {
+	r        io.Reader
+	progress *downloadProgress
+}
+
+// newDownloadProgress returns the progress of a download of total bytes, or nil if w is nil.
+func newDownloadProgress(w io.Writer, total int64) *downloadProgress {
+	if w == nil {
+		return nil
+	}
+
+	p := &downloadProgress{w: w, now: time.Now}
+	p.restart(total)
+
+	return p
+}
+
+// reader returns a reader that reports the progress of the bytes read from r.
+func (p *downloadProgress) reader(r io.Reader) io.Reader {
+	if p == nil {
+		return r
+	}
+
+	return &progressReader{r: r, progress: p}
+}
+
+// restart resets the progress when a download starts again from the beginning.
+func (p *downloadProgress) restart(total int64) {
+	if p == nil {
+		return
+	}
+
+	p.total = total
+	p.current = 0
+	p.reported = 0
+	p.start = p.now()
+	p.last = p.start
+}
+
+// finish writes the final progress line of a download, unless the last line was already for all the bytes.
+func (p *downloadProgress) finish() {
+	if p == nil || p.reported == p.current && p.current > 0 {
+		return
+	}
+
+	p.report()
+}
+
+// add adds read bytes to the progress, and writes a progress line if the interval has passed since the last one.
+func (p *downloadProgress) add(n int) {
+	p.current += int64(n)
+
+	if p.now().Sub(p.last) >= progressInterval {
+		p.report()
+	}
+}
+
+// report writes a progress line with the bytes downloaded, the percentage if the size is known, and the speed.
+func (p *downloadProgress) report() {
+	p.last = p.now()
+	p.reported = p.current
+
+	speed := int64(0)
+	if elapsed := p.last.Sub(p.start).Seconds(); elapsed > 0 {
+		speed = int64(float64(p.current) / elapsed)
+	}
+
+	if p.total <= 0 {
+		fmt.Fprintf(p.w, "Downloaded %s at %s/s.\n", FormatBytes(p.current), FormatBytes(speed))
+		return
+	}
+
+	fmt.Fprintf(p.w, "Downloaded %s of %s (%d%%) at %s/s.\n", FormatBytes(p.current), FormatBytes(p.total), p.current*100/p.total, FormatBytes(speed))
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.progress.add(n)
+
+	return n, err
+}
@@ -0,0 +1,130 @@
+package toolcache
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		size int64
+		want string
+	}{
+		{name: "formats_bytes", size: 512, want: "512 B"},
+		{name: "formats_kibibytes", size: 1536, want: "1.5 KiB"},
+		{name: "formats_mebibytes", size: 300 << 20, want: "300.0 MiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			is.Equal(FormatBytes(tt.size), tt.want) // should match expected
+		})
+	}
+}
+
+func TestDownloadProgress(t *testing.T) {
+	tests := []struct {
+		name  string
+		total int64
+		want  []string
+	}{
+		{
+			name:  "reports_percentage_with_known_size",
+			total: 4 << 20,
+			want: []string{
+				"Downloaded 2.0 MiB of 4.0 MiB (50%) at 1.0 MiB/s.",
+				"Downloaded 4.0 MiB of 4.0 MiB (100%) at 1.0 MiB/s.",
+			},
+		},
+		{
+			name:  "reports_bytes_with_unknown_size",
+			total: -1,
+			want: []string{
+				"Downloaded 2.0 MiB at 1.0 MiB/s.",
+				"Downloaded 4.0 MiB at 1.0 MiB/s.",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			buf := new(bytes.Buffer)
+
+			// Reads take a second per MiB, so a line is written every 2 MiB.
+			now := time.Unix(0, 0)
+			p := newDownloadProgress(buf, tt.total)
+			p.now = func() time.Time { return now }
+			p.restart(tt.total)
+
+			r := p.reader(&clockReader{r: bytes.NewReader(make([]byte, 4<<20)), tick: func(n int) {
+				now = now.Add(time.Duration(n) * time.Second / (1 << 20))
+			}})
+
+			b := make([]byte, 64<<10)
+			for {
+				if _, err := r.Read(b); err != nil {
+					is.Equal(err, io.EOF) // should read to the end
+					break
+				}
+			}
+
+			p.finish()
+
+			is.Equal(strings.Split(strings.TrimSpace(buf.String()), "\n"), tt.want) // should write throttled lines
+		})
+	}
+}
+
+func TestDownloadProgress_nil(t *testing.T) {
+	is := is.New(t)
+	r := strings.NewReader("file content")
+
+	p := newDownloadProgress(nil, 12)
+
+	is.True(p == nil)        // should not report progress
+	is.Equal(p.reader(r), r) // should not wrap the reader
+	p.finish()
+}
+
+func TestDownloadTool_progress(t *testing.T) {
+	is := is.New(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "file content")
+	}))
+	defer ts.Close()
+
+	t.Setenv("RUNNER_TEMP", t.TempDir())
+	buf := new(bytes.Buffer)
+
+	u, _ := url.Parse(ts.URL + "/tool")
+	_, err := DownloadTool(t.Context(), nil, *u, DownloadOptions{Progress: buf})
+
+	is.NoErr(err)                                                                  // should not error
+	is.True(strings.HasPrefix(buf.String(), "Downloaded 12 B of 12 B (100%) at ")) // should write the final line
+}
+
+// clockReader is a reader that advances a clock by the bytes read.
+type clockReader struct {
+	r    io.Reader
+	tick func(n int)
+}
+
+func (r *clockReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.tick(n)
+
+	return n, err
+}
@@ -0,0 +1,192 @@
+package toolcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// PruneOptions is the set of policies used to prune the GitHub Actions runner tool cache.
+// An entry is pruned if it is selected by at least one of KeepNewest, OlderThan or Incomplete
+// and its version does not satisfy KeepVersionSpec.
+type PruneOptions struct {
+	// Tool limits pruning to a single tool. If empty, all tools are pruned.
+	Tool string
+	// KeepNewest prunes all but the N newest complete versions of each tool and architecture.
+	KeepNewest int
+	// OlderThan prunes entries that were cached longer ago than the duration.
+	OlderThan time.Duration
+	// Incomplete prunes entries without a completion marker.
+	Incomplete bool
+	// KeepVersionSpec protects entries whose version satisfies the semver constraint.
+	KeepVersionSpec string
+	// DryRun returns the entries that would be pruned without removing them.
+	DryRun bool
+}
+
+// PrunedEntry is a tool cache entry selected for removal by PruneToolCache.
+// The size includes the entry's marker and sidecars, as in the disk usage of the entry.
+type PrunedEntry struct {
+	CacheEntry
+	Size int64
+}
+
+// PruneToolCache removes entries from the GitHub Actions runner tool cache according to the options.
+// Each entry is removed under its tool lock, and is kept if it was completed or removed by another job while waiting
+// for the lock. It returns the pruned entries, sorted by path.
+func PruneToolCache(ctx context.Context, options PruneOptions) ([]PrunedEntry, error) {
+	if options.KeepNewest < 0 {
+		return nil, fmt.Errorf("keepNewest must not be negative")
+	}
+
+	if options.OlderThan < 0 {
+		return nil, fmt.Errorf("olderThan must not be negative")
+	}
+
+	if options.KeepNewest == 0 && options.OlderThan == 0 && !options.Incomplete {
+		return nil, fmt.Errorf("no prune policy is defined")
+	}
+
+	var keep *semver.Constraints
+	if options.KeepVersionSpec != "" {
+		c, err := semver.NewConstraint(options.KeepVersionSpec)
+		if err != nil {
+			return nil, err
+		}
+		keep = c
+	}
+
+	entries, err := ListCacheEntries(options.Tool)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]bool, len(entries))
+
+	if options.Incomplete {
+		for i, e := range entries {
+			if !e.Complete {
+				selected[i] = true
+			}
+		}
+	}
+
+	if options.OlderThan > 0 {
+		cutoff := time.Now().Add(-options.OlderThan)
+		for i, e := range entries {
+			if e.ModTime.Before(cutoff) {
+				selected[i] = true
+			}
+		}
+	}
+
+	if options.KeepNewest > 0 {
+		for _, i := range selectAllButNewest(entries, options.KeepNewest) {
+			selected[i] = true
+		}
+	}
+
+	pruned := []PrunedEntry{}
+
+	for i, e := range entries {
+		if !selected[i] {
+			continue
+		}
+
+		if keep != nil {
+			if v, err := ParseVersion(e.Version, false); err == nil && keep.Check(v) {
+				continue
+			}
+		}
+
+		size, err := getEntrySize(e.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		pruned = append(pruned, PrunedEntry{CacheEntry: e, Size: size})
+	}
+
+	sort.Slice(pruned, func(i, j int) bool {
+		return pruned[i].Path < pruned[j].Path
+	})
+
+	if options.DryRun {
+		return pruned, nil
+	}
+
+	removed := []PrunedEntry{}
+	for _, e := range pruned {
+		ok, err := removePrunedEntry(ctx, e)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			removed = append(removed, e)
+		}
+	}
+
+	return removed, nil
+}
+
+// removePrunedEntry removes a pruned entry under its tool lock, if it's still in the state it was selected in.
+// It returns false if the entry was kept.
+func removePrunedEntry(ctx context.Context, e PrunedEntry) (bool, error) {
+	lock, current, err := lockCacheEntry(ctx, e.CacheEntry)
+	if err != nil {
+		return false, err
+	}
+
+	if current == nil || current.Complete != e.Complete {
+		return false, lock.Close()
+	}
+
+	err = removeCacheEntry(e.Path)
+	return err == nil, errors.Join(err, lock.Close())
+}
+
+// selectAllButNewest returns the indexes of the complete entries that are not among the n newest
+// versions of their tool and architecture. Entries without a semver version are never selected.
+func selectAllButNewest(entries []CacheEntry, n int) []int {
+	type candidate struct {
+		index int
+		ver   *semver.Version
+	}
+
+	groups := map[string][]candidate{}
+	for i, e := range entries {
+		if !e.Complete {
+			continue
+		}
+
+		v, err := ParseVersion(e.Version, false)
+		if err != nil {
+			continue
+		}
+
+		key := e.Tool + "/" + e.Arch
+		groups[key] = append(groups[key], candidate{index: i, ver: v})
+	}
+
+	indexes := []int{}
+	for _, candidates := range groups {
+		if len(candidates) <= n {
+			continue
+		}
+
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[j].ver.LessThan(candidates[i].ver)
+		})
+
+		for _, c := range candidates[n:] {
+			indexes = append(indexes, c.index)
+		}
+	}
+
+	return indexes
+}
@@ -0,0 +1,162 @@
+package toolcache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+
+	"github.com/action-stars/ghactl/internal/fileio"
+)
+
+func TestPruneToolCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  PruneOptions
+		want     []string
+		wantKept []string
+		wantErr  bool
+	}{
+		{
+			name:    "errors_if_no_policy_is_defined",
+			options: PruneOptions{},
+			wantErr: true,
+		},
+		{
+			name:    "errors_if_keep_newest_is_negative",
+			options: PruneOptions{KeepNewest: -1},
+			wantErr: true,
+		},
+		{
+			name:    "errors_if_keep_version_spec_is_invalid",
+			options: PruneOptions{Incomplete: true, KeepVersionSpec: "bad"},
+			wantErr: true,
+		},
+		{
+			name:     "prunes_incomplete_entries",
+			options:  PruneOptions{Incomplete: true},
+			want:     []string{"tool-a/1.3.0/x64"},
+			wantKept: []string{"tool-a/1.0.0/x64", "tool-a/1.1.0/x64", "tool-a/1.2.0/x64", "tool-a/1.2.0/arm64", "tool-b/2.0.0/x64"},
+		},
+		{
+			name:     "prunes_all_but_the_newest_versions_per_tool_and_arch",
+			options:  PruneOptions{KeepNewest: 1},
+			want:     []string{"tool-a/1.0.0/x64", "tool-a/1.1.0/x64"},
+			wantKept: []string{"tool-a/1.2.0/x64", "tool-a/1.2.0/arm64", "tool-a/1.3.0/x64", "tool-b/2.0.0/x64"},
+		},
+		{
+			name:     "prunes_entries_older_than_duration",
+			options:  PruneOptions{OlderThan: 24 * time.Hour},
+			want:     []string{"tool-a/1.0.0/x64"},
+			wantKept: []string{"tool-a/1.1.0/x64", "tool-b/2.0.0/x64"},
+		},
+		{
+			name:     "keeps_entries_matching_the_version_spec",
+			options:  PruneOptions{KeepNewest: 1, KeepVersionSpec: "~1.0.0"},
+			want:     []string{"tool-a/1.1.0/x64"},
+			wantKept: []string{"tool-a/1.0.0/x64"},
+		},
+		{
+			name:     "limits_pruning_to_a_tool",
+			options:  PruneOptions{Tool: "tool-b", OlderThan: time.Nanosecond},
+			want:     []string{"tool-b/2.0.0/x64"},
+			wantKept: []string{"tool-a/1.0.0/x64"},
+		},
+		{
+			name:     "does_not_remove_entries_on_dry_run",
+			options:  PruneOptions{KeepNewest: 1, DryRun: true},
+			want:     []string{"tool-a/1.0.0/x64", "tool-a/1.1.0/x64"},
+			wantKept: []string{"tool-a/1.0.0/x64", "tool-a/1.1.0/x64"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			tc := t.TempDir()
+			t.Setenv(runnerToolCacheLookup, tc)
+
+			old := mustCreateTestCacheEntry(t, tc, "tool-a", "1.0.0", "x64", true)
+			mustCreateTestCacheEntry(t, tc, "tool-a", "1.1.0", "x64", true)
+			mustCreateTestCacheEntry(t, tc, "tool-a", "1.2.0", "x64", true)
+			mustCreateTestCacheEntry(t, tc, "tool-a", "1.2.0", "arm64", true)
+			mustCreateTestCacheEntry(t, tc, "tool-a", "1.3.0", "x64", false)
+			mustCreateTestCacheEntry(t, tc, "tool-b", "2.0.0", "x64", true)
+
+			past := time.Now().Add(-48 * time.Hour)
+			if err := os.Chtimes(getMarkerPath(old), past, past); err != nil {
+				t.Fatal(err)
+			}
+
+			pruned, err := PruneToolCache(t.Context(), tt.options)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err) // should not error
+
+			got := []string{}
+			for _, e := range pruned {
+				rel, _ := filepath.Rel(tc, e.Path)
+				got = append(got, filepath.ToSlash(rel))
+				is.True(e.Size > 0) // should report size
+
+				exists, _ := fileio.DirExists(e.Path)
+				is.Equal(exists, tt.options.DryRun) // should only be removed when not a dry run
+			}
+			is.Equal(got, tt.want) // should prune expected entries
+
+			for _, k := range tt.wantKept {
+				exists, _ := fileio.DirExists(filepath.Join(tc, filepath.FromSlash(k)))
+				is.True(exists) // should keep entry
+			}
+		})
+	}
+}
+
+func TestPruneToolCache_locking(t *testing.T) {
+	is := is.New(t)
+	tc := t.TempDir()
+	t.Setenv(runnerToolCacheLookup, tc)
+
+	p := mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "x64", false)
+
+	// Another job is installing the tool, and writes its marker before releasing the lock.
+	lock, err := LockTool(t.Context(), "tool", "1.0.0", "x64")
+	is.NoErr(err) // should not error
+
+	go func() {
+		time.Sleep(lockPollInterval)
+		_ = os.WriteFile(getMarkerPath(p), nil, 0o644)
+		_ = lock.Close()
+	}()
+
+	pruned, err := PruneToolCache(t.Context(), PruneOptions{Incomplete: true})
+
+	is.NoErr(err)            // should not error
+	is.Equal(len(pruned), 0) // should not prune the entry completed while waiting for the lock
+
+	exists, _ := fileio.DirExists(p)
+	is.True(exists) // should keep the installed tool
+}
+
+func TestPruneToolCache_size(t *testing.T) {
+	is := is.New(t)
+	tc := t.TempDir()
+	t.Setenv(runnerToolCacheLookup, tc)
+
+	p := mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "x64", true)
+	mustCreateTestFile(t, getMetadataPath(p), `{"tool":"tool"}`)
+
+	pruned, err := PruneToolCache(t.Context(), PruneOptions{OlderThan: time.Nanosecond, DryRun: true})
+	is.NoErr(err)            // should not error
+	is.Equal(len(pruned), 1) // should select the entry
+
+	usage, err := GetDiskUsage("tool")
+	is.NoErr(err)                                      // should not error
+	is.Equal(pruned[0].Size, usage[0].Entries[0].Size) // should report the same size as the disk usage
+}
@@ -0,0 +1,75 @@
+package toolcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// RemoveTool removes the entries of a tool matching the version constraint from the GitHub Actions runner tool cache.
+// If arch is empty, the matching entries for all architectures are removed.
+// Each entry is removed under its tool lock, so an entry being cached by another job is removed once it's cached.
+// It returns the paths of the removed entries.
+func RemoveTool(ctx context.Context, tool, arch, versionSpec string) ([]string, error) {
+	if tool == "" {
+		return nil, fmt.Errorf("tool is not defined")
+	}
+
+	if versionSpec == "" {
+		return nil, fmt.Errorf("versionSpec is not defined")
+	}
+
+	c, err := semver.NewConstraint(versionSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := ListCacheEntries(tool)
+	if err != nil {
+		return nil, err
+	}
+
+	archSegment := PlatformArch("", arch)
+
+	removed := []string{}
+
+	for _, e := range entries {
+		if arch != "" && e.Arch != archSegment {
+			continue
+		}
+
+		v, err := ParseVersion(e.Version, false)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+
+		ok, err := removeLockedEntry(ctx, e)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			removed = append(removed, e.Path)
+		}
+	}
+
+	return removed, nil
+}
+
+// removeLockedEntry removes a cache entry under its tool lock. It returns false if the entry was removed by another
+// job while waiting for the lock.
+func removeLockedEntry(ctx context.Context, e CacheEntry) (bool, error) {
+	lock, current, err := lockCacheEntry(ctx, e)
+	if err != nil {
+		return false, err
+	}
+
+	if current == nil {
+		return false, lock.Close()
+	}
+
+	err = removeCacheEntry(e.Path)
+	return err == nil, errors.Join(err, lock.Close())
+}
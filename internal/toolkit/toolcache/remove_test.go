@@ -0,0 +1,131 @@
+package toolcache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+
+	"github.com/action-stars/ghactl/internal/fileio"
+)
+
+func TestRemoveTool(t *testing.T) {
+	tests := []struct {
+		name        string
+		tool        string
+		arch        string
+		versionSpec string
+		want        []string
+		wantKept    []string
+		wantErr     bool
+	}{
+		{
+			name:        "errors_if_tool_is_not_defined",
+			tool:        "",
+			versionSpec: "1.0.0",
+			wantErr:     true,
+		},
+		{
+			name:        "errors_if_version_spec_is_not_defined",
+			tool:        "tool",
+			versionSpec: "",
+			wantErr:     true,
+		},
+		{
+			name:        "errors_if_version_spec_is_invalid",
+			tool:        "tool",
+			versionSpec: "bad",
+			wantErr:     true,
+		},
+		{
+			name:        "removes_nothing_if_tool_is_not_cached",
+			tool:        "other-tool",
+			versionSpec: "*",
+			want:        []string{},
+		},
+		{
+			name:        "removes_explicit_version_for_arch",
+			tool:        "tool",
+			arch:        "amd64",
+			versionSpec: "1.0.0",
+			want:        []string{"tool/1.0.0/x64"},
+			wantKept:    []string{"tool/1.0.0/arm64", "tool/1.1.0/x64"},
+		},
+		{
+			name:        "removes_matching_versions_for_all_archs",
+			tool:        "tool",
+			versionSpec: "~1.0.0",
+			want:        []string{"tool/1.0.0/arm64", "tool/1.0.0/x64"},
+			wantKept:    []string{"tool/1.1.0/x64"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			tc := t.TempDir()
+			t.Setenv(runnerToolCacheLookup, tc)
+
+			mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "x64", true)
+			mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "arm64", true)
+			mustCreateTestCacheEntry(t, tc, "tool", "1.1.0", "x64", true)
+
+			removed, err := RemoveTool(t.Context(), tt.tool, tt.arch, tt.versionSpec)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err) // should not error
+
+			got := []string{}
+			for _, p := range removed {
+				rel, _ := filepath.Rel(tc, p)
+				got = append(got, filepath.ToSlash(rel))
+
+				dirExists, _ := fileio.DirExists(p)
+				markerExists, _ := fileio.FileExists(getMarkerPath(p))
+				is.True(!dirExists)    // dir should be removed
+				is.True(!markerExists) // marker should be removed
+			}
+			is.Equal(got, tt.want) // should remove expected entries
+
+			for _, k := range tt.wantKept {
+				exists, _ := fileio.DirExists(filepath.Join(tc, filepath.FromSlash(k)))
+				is.True(exists) // should keep entry
+			}
+		})
+	}
+}
+
+func TestRemoveTool_locking(t *testing.T) {
+	is := is.New(t)
+	tc := t.TempDir()
+	t.Setenv(runnerToolCacheLookup, tc)
+
+	p := mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "x64", true)
+
+	// Another job holds the lock for the tool, such as while caching it.
+	lock, err := LockTool(t.Context(), "tool", "1.0.0", "x64")
+	is.NoErr(err) // should not error
+
+	done := make(chan []string)
+	go func() {
+		removed, _ := RemoveTool(t.Context(), "tool", "", "*")
+		done <- removed
+	}()
+
+	time.Sleep(2 * lockPollInterval)
+	exists, _ := fileio.DirExists(p)
+	is.True(exists) // should not remove the entry while it's locked
+
+	is.NoErr(lock.Close()) // should release
+
+	removed := <-done
+	is.Equal(removed, []string{p}) // should remove the entry once the lock is released
+
+	exists, _ = fileio.DirExists(p)
+	is.True(!exists) // should remove the entry
+}
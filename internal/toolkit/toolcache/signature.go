@@ -0,0 +1,106 @@
+package toolcache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+)
+
+// maxSignatureFileSize is the maximum size of a downloaded signature file.
+const maxSignatureFileSize = 64 << 10
+
+// SignatureFormat is the format of a detached signature.
+type SignatureFormat string
+
+const (
+	// Minisign is a minisign Ed25519 signature, in a .minisig file.
+	Minisign SignatureFormat = "minisign"
+	// SSH is an SSH signature, as created by ssh-keygen -Y sign.
+	SSH SignatureFormat = "ssh"
+	// OpenPGP is an armored or binary OpenPGP detached signature, in a .asc or .sig file.
+	OpenPGP SignatureFormat = "openpgp"
+)
+
+// DefaultSSHNamespace is the default namespace of SSH signatures, the namespace used by ssh-keygen for files.
+const DefaultSSHNamespace = "file"
+
+// SignatureExtensions are the extensions of the signature files published next to a download, in the order they're
+// looked for.
+var SignatureExtensions = []string{".minisig", ".sig", ".asc"}
+
+// ErrSignatureNotFound is returned when a signature file doesn't exist.
+var ErrSignatureNotFound = errors.New("signature not found")
+
+// Signature is a detached signature of a download and the public key to verify it with.
+// Signatures are verified without network access, against the public key only.
+type Signature struct {
+	// Data is the minisign, SSH or OpenPGP detached signature. The format is detected from its content.
+	Data []byte
+	// PublicKey is a minisign public key, SSH authorized keys, or an armored or binary OpenPGP public key ring.
+	PublicKey []byte
+	// Namespace is the namespace of an SSH signature, or DefaultSSHNamespace if it's empty.
+	Namespace string
+}
+
+// VerifySignature verifies the file at a path against a detached signature and returns the signature's format.
+func VerifySignature(p string, signature Signature) (SignatureFormat, error) {
+	format, err := getSignatureFormat(signature.Data)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case Minisign:
+		err = verifyMinisign(p, signature.Data, signature.PublicKey)
+	case SSH:
+		namespace := signature.Namespace
+		if namespace == "" {
+			namespace = DefaultSSHNamespace
+		}
+		err = verifySSHSignature(p, signature.Data, signature.PublicKey, namespace)
+	case OpenPGP:
+		err = verifyOpenPGPSignature(p, signature.Data, signature.PublicKey)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("verifying %s signature: %w", format, err)
+	}
+
+	return format, nil
+}
+
+// DownloadSignature downloads a signature file from a URL, as described for downloadSmallFile.
+// It returns ErrSignatureNotFound if the server responds 404 Not Found.
+func DownloadSignature(ctx context.Context, logger any, u url.URL, options DownloadOptions) ([]byte, error) {
+	data, err := downloadSmallFile(ctx, logger, u, options, maxSignatureFileSize)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrSignatureNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("downloading signature file: %w", err)
+	}
+
+	return data, nil
+}
+
+// getSignatureFormat detects the format of a detached signature from its content.
+func getSignatureFormat(data []byte) (SignatureFormat, error) {
+	trimmed := bytes.TrimSpace(data)
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("untrusted comment:")):
+		return Minisign, nil
+	case bytes.HasPrefix(trimmed, []byte(sshSignatureArmorStart)):
+		return SSH, nil
+	case bytes.HasPrefix(trimmed, []byte(openPGPSignatureArmorStart)):
+		return OpenPGP, nil
+	case len(data) > 0 && data[0]&0x80 != 0:
+		// Binary OpenPGP packets have the high bit of the first byte set.
+		return OpenPGP, nil
+	default:
+		return "", fmt.Errorf("signature is not in minisign, SSH or OpenPGP format")
+	}
+}
@@ -0,0 +1,354 @@
+package toolcache
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/matryer/is"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestVerifySignature(t *testing.T) {
+	content := "file content"
+	p := filepath.Join(t.TempDir(), "tool")
+	mustCreateTestFile(t, p, content)
+
+	minisignSig, minisignKey := createMinisignSignature(t, []byte(content), true)
+	legacyMinisignSig, legacyMinisignKey := createMinisignSignature(t, []byte(content), false)
+	_, otherMinisignKey := createMinisignSignature(t, []byte(content), true)
+	tamperedMinisignSig := bytes.Replace(minisignSig, []byte("\n"+minisignTrustedComment), []byte("\n"+minisignTrustedComment+"tampered "), 1)
+	sshSig, sshKey := createSSHSignature(t, []byte(content), DefaultSSHNamespace)
+	_, otherSSHKey := createSSHSignature(t, []byte(content), DefaultSSHNamespace)
+	openPGPSig, openPGPKey := createOpenPGPSignature(t, []byte(content), packet.PubKeyAlgoRSA)
+	ed25519OpenPGPSig, ed25519OpenPGPKey := createOpenPGPSignature(t, []byte(content), packet.PubKeyAlgoEdDSA)
+	_, otherOpenPGPKey := createOpenPGPSignature(t, []byte(content), packet.PubKeyAlgoEdDSA)
+	otherSSHSig, _ := createSSHSignature(t, []byte("other content"), DefaultSSHNamespace)
+
+	tests := []struct {
+		name      string
+		signature Signature
+		want      SignatureFormat
+		wantErr   bool
+	}{
+		{
+			name:      "verifies_minisign_signature",
+			signature: Signature{Data: minisignSig, PublicKey: minisignKey},
+			want:      Minisign,
+		},
+		{
+			name:      "verifies_legacy_minisign_signature",
+			signature: Signature{Data: legacyMinisignSig, PublicKey: legacyMinisignKey},
+			want:      Minisign,
+		},
+		{
+			name:      "errors_on_minisign_signature_from_other_key",
+			signature: Signature{Data: minisignSig, PublicKey: otherMinisignKey},
+			wantErr:   true,
+		},
+		{
+			name:      "errors_on_tampered_minisign_trusted_comment",
+			signature: Signature{Data: tamperedMinisignSig, PublicKey: minisignKey},
+			wantErr:   true,
+		},
+		{
+			name:      "verifies_ssh_signature",
+			signature: Signature{Data: sshSig, PublicKey: sshKey},
+			want:      SSH,
+		},
+		{
+			name:      "verifies_ssh_signature_with_one_of_several_keys",
+			signature: Signature{Data: sshSig, PublicKey: append(append(otherSSHKey, '\n'), sshKey...)},
+			want:      SSH,
+		},
+		{
+			name:      "errors_on_ssh_signature_from_other_key",
+			signature: Signature{Data: sshSig, PublicKey: otherSSHKey},
+			wantErr:   true,
+		},
+		{
+			name:      "errors_on_ssh_signature_of_other_file",
+			signature: Signature{Data: otherSSHSig, PublicKey: sshKey},
+			wantErr:   true,
+		},
+		{
+			name:      "errors_on_ssh_signature_for_other_namespace",
+			signature: Signature{Data: sshSig, PublicKey: sshKey, Namespace: "other"},
+			wantErr:   true,
+		},
+		{
+			name:      "verifies_openpgp_signature",
+			signature: Signature{Data: openPGPSig, PublicKey: openPGPKey},
+			want:      OpenPGP,
+		},
+		{
+			name:      "verifies_openpgp_ed25519_signature",
+			signature: Signature{Data: ed25519OpenPGPSig, PublicKey: ed25519OpenPGPKey},
+			want:      OpenPGP,
+		},
+		{
+			name:      "errors_on_openpgp_signature_from_other_key",
+			signature: Signature{Data: openPGPSig, PublicKey: otherOpenPGPKey},
+			wantErr:   true,
+		},
+		{
+			name:      "errors_on_public_key_in_other_format",
+			signature: Signature{Data: minisignSig, PublicKey: sshKey},
+			wantErr:   true,
+		},
+		{
+			name:      "errors_on_unknown_signature_format",
+			signature: Signature{Data: []byte("not a signature"), PublicKey: sshKey},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			got, err := VerifySignature(p, tt.signature)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err)          // should not error
+			is.Equal(got, tt.want) // should return the signature format
+		})
+	}
+}
+
+func TestDownloadSignature(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tool.minisig":
+			fmt.Fprint(w, "signature")
+		case "/large.sig":
+			fmt.Fprint(w, strings.Repeat("a", maxSignatureFileSize+1))
+		case "/error.sig":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "downloads_signature",
+			path: "/tool.minisig",
+			want: "signature",
+		},
+		{
+			name:    "errors_if_signature_is_not_found",
+			path:    "/tool.asc",
+			wantErr: ErrSignatureNotFound,
+		},
+		{
+			name:    "errors_on_unexpected_status",
+			path:    "/error.sig",
+			wantErr: errors.New("unexpected status"),
+		},
+		{
+			name:    "errors_if_signature_is_too_large",
+			path:    "/large.sig",
+			wantErr: errors.New("larger than"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			u, _ := url.Parse(ts.URL + tt.path)
+
+			got, err := DownloadSignature(t.Context(), nil, *u, DownloadOptions{Retry: &RetryPolicy{}})
+
+			if tt.wantErr != nil {
+				is.True(err != nil)                                                                      // should error
+				is.True(errors.Is(err, tt.wantErr) || strings.Contains(err.Error(), tt.wantErr.Error())) // should return the expected error
+				return
+			}
+
+			is.NoErr(err)                  // should not error
+			is.Equal(string(got), tt.want) // should return the signature
+		})
+	}
+}
+
+func TestDownloadTool_signature(t *testing.T) {
+	content := "file content"
+	sig, key := createMinisignSignature(t, []byte(content), true)
+	_, otherKey := createMinisignSignature(t, []byte(content), true)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, content)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name      string
+		signature *Signature
+		want      SignatureFormat
+		wantErr   bool
+	}{
+		{
+			name: "does_not_verify_without_signature",
+		},
+		{
+			name:      "verifies_signature",
+			signature: &Signature{Data: sig, PublicKey: key},
+			want:      Minisign,
+		},
+		{
+			name:      "errors_and_deletes_download_on_invalid_signature",
+			signature: &Signature{Data: sig, PublicKey: otherKey},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			dest := t.TempDir()
+			u, _ := url.Parse(ts.URL + "/tool")
+
+			result, err := DownloadTool(t.Context(), nil, *u, DownloadOptions{Dest: dest + string(filepath.Separator), Signature: tt.signature})
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+
+				items, _ := filepath.Glob(filepath.Join(dest, "*"))
+				is.Equal(len(items), 0) // should delete the download
+				return
+			}
+
+			is.NoErr(err)                       // should not error
+			is.Equal(result.Signature, tt.want) // should return the verified signature format
+		})
+	}
+}
+
+// createMinisignSignature signs data with a new minisign key, and returns the signature and public key files.
+func createMinisignSignature(t *testing.T, data []byte, hashed bool) ([]byte, []byte) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyID := make([]byte, minisignKeyIDSize)
+	if _, err := rand.Read(keyID); err != nil {
+		t.Fatal(err)
+	}
+
+	algorithm, message := minisignAlgorithm, data
+	if hashed {
+		h := blake2b.Sum512(data)
+		algorithm, message = minisignHashedAlgorithm, h[:]
+	}
+
+	sig := ed25519.Sign(priv, message)
+	trustedComment := "timestamp:1700000000\tfile:tool"
+	globalSig := ed25519.Sign(priv, append(bytes.Clone(sig), trustedComment...))
+
+	encode := func(parts ...[]byte) string {
+		return base64.StdEncoding.EncodeToString(bytes.Join(parts, nil))
+	}
+
+	signature := "untrusted comment: signature from minisign secret key\n" +
+		encode([]byte(algorithm), keyID, sig) + "\n" +
+		minisignTrustedComment + trustedComment + "\n" +
+		encode(globalSig) + "\n"
+	publicKey := "untrusted comment: minisign public key\n" + encode([]byte(minisignAlgorithm), keyID, pub) + "\n"
+
+	return []byte(signature), []byte(publicKey)
+}
+
+// createSSHSignature signs data with a new SSH key for a namespace, and returns the armored signature and the public
+// key in authorized keys format.
+func createSSHSignature(t *testing.T, data []byte, namespace string) ([]byte, []byte) {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := sha512.Sum512(data)
+	signed := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignedData{Namespace: namespace, HashAlgorithm: "sha512", Hash: h[:]})...)
+
+	sig, err := signer.Sign(rand.Reader, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignature{
+		Version:       sshSignatureVersion,
+		PublicKey:     signer.PublicKey().Marshal(),
+		Namespace:     namespace,
+		HashAlgorithm: "sha512",
+		Signature:     ssh.Marshal(sig),
+	})...)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "SSH SIGNATURE", Bytes: blob}), ssh.MarshalAuthorizedKey(signer.PublicKey())
+}
+
+// createOpenPGPSignature signs data with a new OpenPGP key of an algorithm, and returns the armored signature and public
+// key.
+func createOpenPGPSignature(t *testing.T, data []byte, algorithm packet.PublicKeyAlgorithm) ([]byte, []byte) {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity("ghactl", "", "ghactl@example.com", &packet.Config{Algorithm: algorithm})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sig bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&sig, entity, bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var key bytes.Buffer
+	w, err := armor.Encode(&key, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return sig.Bytes(), key.Bytes()
+}
@@ -0,0 +1,154 @@
+package toolcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/pem"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// sshSignatureArmorStart is the first line of an armored SSH signature.
+	sshSignatureArmorStart = "-----BEGIN SSH SIGNATURE-----"
+	// sshSignatureMagic is the preamble of SSH signature blobs and of the data they sign.
+	sshSignatureMagic = "SSHSIG"
+	// sshSignatureVersion is the supported version of SSH signatures.
+	sshSignatureVersion = 1
+)
+
+// sshSignature is the blob of an SSH signature, after its preamble.
+type sshSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is the data signed by an SSH signature, after its preamble.
+type sshSignedData struct {
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Hash          []byte
+}
+
+// verifySSHSignature verifies the file at a path against an armored SSH signature, as created by ssh-keygen -Y sign,
+// for a namespace. The signing key must be one of the keys in authorized keys format, one per line, in the public key.
+func verifySSHSignature(p string, signature, publicKey []byte, namespace string) error {
+	keys, err := parseSSHPublicKeys(publicKey)
+	if err != nil {
+		return err
+	}
+
+	block, _ := pem.Decode(bytes.TrimSpace(signature))
+	if block == nil || block.Type != "SSH SIGNATURE" {
+		return fmt.Errorf("signature is not armored")
+	}
+
+	blob, ok := bytes.CutPrefix(block.Bytes, []byte(sshSignatureMagic))
+	if !ok {
+		return fmt.Errorf("signature has no %s preamble", sshSignatureMagic)
+	}
+
+	var sig sshSignature
+	if err := ssh.Unmarshal(blob, &sig); err != nil {
+		return fmt.Errorf("parsing signature: %w", err)
+	}
+
+	if sig.Version != sshSignatureVersion {
+		return fmt.Errorf("signature version %d is not supported", sig.Version)
+	}
+
+	if sig.Namespace != namespace {
+		return fmt.Errorf("signature namespace %q doesn't match %q", sig.Namespace, namespace)
+	}
+
+	signer, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return fmt.Errorf("parsing signature public key: %w", err)
+	}
+
+	trusted := false
+	for _, k := range keys {
+		if bytes.Equal(k.Marshal(), signer.Marshal()) {
+			trusted = true
+			break
+		}
+	}
+
+	if !trusted {
+		return fmt.Errorf("signature key %s is not the public key", ssh.FingerprintSHA256(signer))
+	}
+
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("signature hash algorithm %q is not supported", sig.HashAlgorithm)
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	var s ssh.Signature
+	if err := ssh.Unmarshal(sig.Signature, &s); err != nil {
+		return fmt.Errorf("parsing signature: %w", err)
+	}
+
+	// RSA signatures with SHA-1 aren't accepted, as with ssh-keygen.
+	if s.Format == ssh.KeyAlgoRSA {
+		return fmt.Errorf("signature algorithm %s is not supported", s.Format)
+	}
+
+	data := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignedData{
+		Namespace:     sig.Namespace,
+		Reserved:      sig.Reserved,
+		HashAlgorithm: sig.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...)
+
+	if err := signer.Verify(data, &s); err != nil {
+		return fmt.Errorf("signature doesn't match the file: %w", err)
+	}
+
+	return nil
+}
+
+// parseSSHPublicKeys parses the public keys in authorized keys or allowed signers format, one per line.
+func parseSSHPublicKeys(data []byte) ([]ssh.PublicKey, error) {
+	keys := []ssh.PublicKey{}
+
+	rest := data
+	for len(bytes.TrimSpace(rest)) > 0 {
+		key, _, _, r, err := ssh.ParseAuthorizedKey(rest)
+		if err != nil {
+			break
+		}
+		keys = append(keys, key)
+		rest = r
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("public key has no SSH public keys")
+	}
+
+	return keys, nil
+}
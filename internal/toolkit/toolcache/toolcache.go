@@ -6,157 +6,271 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
 
 	"github.com/action-stars/ghactl/internal/fileio"
 )
 
-// runnerToolCacheLookup is the environment variable used to find the GitHub Actions runner tool cache directory.
-const runnerToolCacheLookup = "RUNNER_TOOL_CACHE"
+const (
+	// runnerToolCacheLookup is the environment variable used to find the GitHub Actions runner tool cache directory.
+	runnerToolCacheLookup = "RUNNER_TOOL_CACHE"
+
+	// ghactlToolCacheLookup is the environment variable used to configure the tool cache directory outside of GitHub Actions.
+	ghactlToolCacheLookup = "GHACTL_TOOL_CACHE"
+
+	// xdgCacheHomeLookup is the environment variable used to find the XDG base directory for user cache files.
+	xdgCacheHomeLookup = "XDG_CACHE_HOME"
+
+	// defaultToolCacheSource is the source of the tool cache directory when it defaults to the user cache directory.
+	defaultToolCacheSource = "default"
+)
 
 // GetToolCacheDirectory returns the path to the GitHub Actions runner tool cache directory.
+// See LookupToolCacheDirectory for how the directory is found.
 func GetToolCacheDirectory() (string, error) {
-	d := os.Getenv(runnerToolCacheLookup)
-	if d == "" {
-		return "", fmt.Errorf("%s is not defined", runnerToolCacheLookup)
+	d, _, err := LookupToolCacheDirectory()
+	return d, err
+}
+
+// LookupToolCacheDirectory returns the path to the tool cache directory and the source it was found from.
+// It checks the following in order:
+// 1. RUNNER_TOOL_CACHE (for GitHub Actions runner), which must exist
+// 2. GHACTL_TOOL_CACHE
+// 3. XDG_CACHE_HOME/ghactl/tools
+// 4. ghactl/tools in the user cache directory, with the source "default"
+// Outside of GitHub Actions the directory is created if it doesn't exist.
+func LookupToolCacheDirectory() (string, string, error) {
+	if d := os.Getenv(runnerToolCacheLookup); d != "" {
+		exists, err := fileio.DirExists(d)
+		if err != nil {
+			return "", "", err
+		}
+
+		if !exists {
+			return "", "", fmt.Errorf("dir %s does not exist", d)
+		}
+
+		return d, runnerToolCacheLookup, nil
 	}
 
-	exists, err := fileio.DirExists(d)
-	if err != nil {
-		return "", err
+	d, source := os.Getenv(ghactlToolCacheLookup), ghactlToolCacheLookup
+	if d == "" {
+		// Relative XDG paths are invalid, so they are left for os.UserCacheDir to reject.
+		if xdg := os.Getenv(xdgCacheHomeLookup); filepath.IsAbs(xdg) {
+			d, source = filepath.Join(xdg, "ghactl", "tools"), xdgCacheHomeLookup
+		} else {
+			userCacheDir, err := os.UserCacheDir()
+			if err != nil {
+				return "", "", fmt.Errorf("no tool cache directory found: %w", err)
+			}
+			d, source = filepath.Join(userCacheDir, "ghactl", "tools"), defaultToolCacheSource
+		}
 	}
 
-	if !exists {
-		return "", fmt.Errorf("dir %s does not exist", d)
+	if err := os.MkdirAll(d, 0o755); err != nil {
+		return "", "", err
 	}
 
-	return d, nil
+	return d, source, nil
 }
 
-// FindAllToolVersions returns all versions of a tool in the GitHub Actions runner tool cache.
-func FindAllToolVersions(tool, arch string) ([]string, error) {
-	d, err := GetToolCacheDirectory()
+// FindOptions are the options used to find tools in the GitHub Actions runner tool cache.
+type FindOptions struct {
+	// Strict only accepts version directories that are strict semver versions.
+	// Otherwise versions are parsed with ParseVersion, so loose versions such as v1.2.3 or 1.22 are found.
+	Strict bool
+	// OS is the OS the tool was built for. If empty, the tool is found for the host OS, unless the architecture is qualified with an OS.
+	OS string
+	// ArchFallbacks are the architectures, in order of preference, to search when no version matches for the requested architecture.
+	ArchFallbacks []string
+	// IncludePrerelease matches prerelease versions against constraints without a prerelease, so ^1.0.0 matches 1.1.0-rc.1.
+	IncludePrerelease bool
+	// Prefer is the policy used to select a version when several versions match. If empty, PreferNewest is used.
+	Prefer VersionPreference
+}
+
+// VersionPreference is the policy used by FindTool to select a version when several cached versions match.
+type VersionPreference string
+
+const (
+	// PreferNewest selects the highest matching version, even if it's a prerelease.
+	PreferNewest VersionPreference = "newest"
+	// PreferStable selects the highest matching stable version, falling back to the highest prerelease if no stable version matches.
+	PreferStable VersionPreference = "stable"
+)
+
+// ParseVersionPreference parses a version preference, returning PreferNewest if it's empty.
+func ParseVersionPreference(s string) (VersionPreference, error) {
+	switch p := VersionPreference(s); p {
+	case "":
+		return PreferNewest, nil
+	case PreferNewest, PreferStable:
+		return p, nil
+	default:
+		return "", fmt.Errorf("version preference %s is not supported, use %s or %s", s, PreferStable, PreferNewest)
+	}
+}
+
+// FindAllToolVersions returns all versions of a tool in the GitHub Actions runner tool cache, sorted by version.
+// If versionSpec is not empty, only the versions matching the semver constraint are returned;
+// prereleases only match if the constraint has a prerelease or options.IncludePrerelease is set.
+// The versions are returned as their cache directory names, for the first architecture in the fallback chain with a match.
+func FindAllToolVersions(tool, arch, versionSpec string, options FindOptions) ([]string, error) {
+	vs, _, err := findToolVersions(tool, arch, versionSpec, options)
 	if err != nil {
 		return nil, err
 	}
 
-	if tool == "" {
-		return nil, fmt.Errorf("tool is not defined")
+	versions := make([]string, 0, len(vs))
+	for _, v := range vs {
+		versions = append(versions, v.Original())
 	}
 
-	if arch == "" {
-		return nil, fmt.Errorf("arch is not defined")
+	return versions, nil
+}
+
+// FindTool finds a tool in the GitHub Actions runner tool cache that matches the version constraint.
+// If the versionSpec isn't an explicit version then it will be evaluated as a semver constraint.
+// If no version matches for the architecture, the architectures in options.ArchFallbacks are searched in order;
+// the selected architecture is the last element of the returned path.
+// If several versions match, options.Prefer selects between them.
+// Will return the path to the tool or an empty string if no tool is found.
+func FindTool(tool, arch, versionSpec string, options FindOptions) (string, error) {
+	if versionSpec == "" {
+		return "", fmt.Errorf("versionSpec is not defined")
 	}
 
-	toolPath := filepath.Join(d, tool)
-	exists, err := fileio.DirExists(toolPath)
+	prefer, err := ParseVersionPreference(string(options.Prefer))
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	versions := []string{}
+	vs, selectedArch, err := findToolVersions(tool, arch, versionSpec, options)
+	if err != nil {
+		return "", err
+	}
 
-	if !exists {
-		return versions, nil
+	if len(vs) == 0 {
+		return "", nil
 	}
 
-	items, err := os.ReadDir(toolPath)
+	d, err := GetToolCacheDirectory()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	nodeArch := getNodeArch(arch)
+	return filepath.Join(d, tool, selectVersion(vs, prefer).Original(), selectedArch), nil
+}
 
-	for _, item := range items {
-		if item.IsDir() {
-			_, err := semver.StrictNewVersion(item.Name())
-			if err == nil {
-				complete, _ := fileio.FileExists(getMarkerPath(filepath.Join(toolPath, item.Name(), nodeArch)))
-				if complete {
-					versions = append(versions, item.Name())
-				}
+// selectVersion selects a version from a non-empty list of versions sorted by version using the preference.
+func selectVersion(vs []*semver.Version, prefer VersionPreference) *semver.Version {
+	if prefer == PreferStable {
+		for i := len(vs) - 1; i >= 0; i-- {
+			if vs[i].Prerelease() == "" {
+				return vs[i]
 			}
 		}
 	}
 
-	return versions, nil
+	return vs[len(vs)-1]
 }
 
-// FindTool finds a tool in the GitHub Actions runner tool cache that matches the version constraint.
-// If the versionSpec isn't an explicit version then it will be evaluated as a semver constraint.
-// Will return the path to the tool or an empty string if no tool is found.
-func FindTool(tool, arch, versionSpec string) (string, error) {
+// findToolVersions returns the complete versions of a tool matching the version constraint, sorted by version,
+// for the first architecture in the fallback chain with a match, along with that architecture.
+// If versionSpec is empty, all complete versions are returned.
+func findToolVersions(tool, arch, versionSpec string, options FindOptions) ([]*semver.Version, string, error) {
 	d, err := GetToolCacheDirectory()
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
 
 	if tool == "" {
-		return "", fmt.Errorf("tool is not defined")
+		return nil, "", fmt.Errorf("tool is not defined")
 	}
 
 	if arch == "" {
-		return "", fmt.Errorf("arch is not defined")
+		return nil, "", fmt.Errorf("arch is not defined")
 	}
 
-	if versionSpec == "" {
-		return "", fmt.Errorf("versionSpec is not defined")
+	var c *semver.Constraints
+	if versionSpec != "" {
+		c, err = semver.NewConstraint(versionSpec)
+		if err != nil {
+			return nil, "", err
+		}
+		c.IncludePrerelease = options.IncludePrerelease
 	}
 
+	archChain := getArchChain(options.OS, arch, options.ArchFallbacks)
+
 	toolPath := filepath.Join(d, tool)
 	exists, err := fileio.DirExists(toolPath)
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
 
 	if !exists {
-		return "", nil
+		return []*semver.Version{}, archChain[0], nil
 	}
 
 	items, err := os.ReadDir(toolPath)
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
 
-	c, err := semver.NewConstraint(versionSpec)
-	if err != nil {
-		return "", err
-	}
+	for _, nodeArch := range archChain {
+		vs := []*semver.Version{}
+
+		for _, item := range items {
+			if !item.IsDir() {
+				continue
+			}
 
-	vs := []*semver.Version{}
+			v, err := ParseVersion(item.Name(), options.Strict)
+			if err != nil {
+				continue
+			}
 
-	nodeArch := getNodeArch(arch)
+			if c != nil && !c.Check(v) {
+				continue
+			}
 
-	for _, item := range items {
-		if item.IsDir() {
-			v, err := semver.StrictNewVersion(item.Name())
-			if err == nil {
-				complete, _ := fileio.FileExists(getMarkerPath(filepath.Join(toolPath, item.Name(), nodeArch)))
-				if complete {
-					if c.Check(v) {
-						vs = append(vs, v)
-					}
-				}
+			complete, _ := fileio.FileExists(getMarkerPath(filepath.Join(toolPath, item.Name(), nodeArch)))
+			if complete {
+				vs = append(vs, v)
 			}
 		}
-	}
 
-	if len(vs) == 0 {
-		return "", nil
+		if len(vs) > 0 {
+			sort.Stable(semver.Collection(vs))
+			return vs, nodeArch, nil
+		}
 	}
 
-	if len(vs) > 1 {
-		sort.Sort(semver.Collection(vs))
-	}
+	return []*semver.Version{}, archChain[0], nil
+}
 
-	return filepath.Join(toolPath, vs[len(vs)-1].String(), nodeArch), nil
+// CacheDirOptions are the options used to cache a tool dir.
+type CacheDirOptions struct {
+	// Move moves the source into the tool cache with a rename if it's on the same filesystem, instead of copying it.
+	// It's only set by callers that own the source, such as a temporary extraction of a release asset or bundle.
+	Move bool
 }
 
 // CacheDir caches a tool dir into the GitHub Actions runner tool cache.
-// If addPath is true, the tool will be added to the PATH.
-func CacheDir(source, tool, version, arch string) (string, error) {
+// The tool is staged next to its final location and published with an atomic rename,
+// so it is never found in a partially copied state.
+// File modes and relative symlinks are preserved; symlinks that escape the source directory are rejected.
+// If options.Move is set, the source is moved rather than copied when possible.
+// If metadata.OS is not the host OS, the tool is cached for that OS as described by PlatformArch.
+// The metadata is completed with the tool details and written as a sidecar next to the marker;
+// if it has no source, the source directory is recorded.
+func CacheDir(source, tool, version, arch string, metadata Metadata, options CacheDirOptions) (string, error) {
 	sourceExists, err := fileio.DirExists(source)
 	if err != nil {
 		return "", err
@@ -165,30 +279,38 @@ func CacheDir(source, tool, version, arch string) (string, error) {
 		return "", fmt.Errorf("source %s does not exist", source)
 	}
 
-	nodeArch := getNodeArch(arch)
+	osName, nodeArch := splitPlatformArch(arch)
+	if metadata.OS == "" {
+		metadata.OS = osName
+	}
 
-	toolPath, err := createToolPath(tool, version, nodeArch)
+	toolPath, stagingPath, err := createStagingPath(tool, version, PlatformArch(metadata.OS, nodeArch))
 	if err != nil {
 		return "", err
 	}
 
-	if err := os.CopyFS(toolPath, os.DirFS(source)); err != nil {
-		return "", err
+	if err := stageToolDir(source, stagingPath, options.Move); err != nil {
+		return "", errors.Join(err, os.RemoveAll(stagingPath))
 	}
 
-	markerPath := getMarkerPath(toolPath)
-	marker, err := os.Create(markerPath)
-	if err != nil {
-		return "", err
+	if metadata.Source == (MetadataSource{}) {
+		metadata.Source.Path, _ = filepath.Abs(source)
+	}
+
+	if err := publishToolPath(stagingPath, toolPath, completeMetadata(metadata, tool, version, nodeArch)); err != nil {
+		return "", errors.Join(err, os.RemoveAll(stagingPath))
 	}
-	marker.Close()
 
 	return toolPath, nil
 }
 
 // CacheFile caches a tool file into the GitHub Actions runner tool cache.
-// If addPath is true, the tool will be added to the PATH.
-func CacheFile(source, targetName, tool, version, arch string) (string, error) {
+// The tool is staged next to its final location and published with an atomic rename,
+// so it is never found in a partially copied state.
+// If metadata.OS is not the host OS, the tool is cached for that OS as described by PlatformArch.
+// The metadata is completed with the tool details and written as a sidecar next to the marker;
+// if it has no source, the source file and its digest are recorded.
+func CacheFile(source, targetName, tool, version, arch string, metadata Metadata) (string, error) {
 	sourceExists, err := fileio.FileExists(source)
 	if err != nil {
 		return "", err
@@ -201,68 +323,169 @@ func CacheFile(source, targetName, tool, version, arch string) (string, error) {
 		return "", fmt.Errorf("targetName is not defined")
 	}
 
-	nodeArch := getNodeArch(arch)
+	osName, nodeArch := splitPlatformArch(arch)
+	if metadata.OS == "" {
+		metadata.OS = osName
+	}
 
-	toolPath, err := createToolPath(tool, version, nodeArch)
+	toolPath, stagingPath, err := createStagingPath(tool, version, PlatformArch(metadata.OS, nodeArch))
 	if err != nil {
 		return "", err
 	}
 
-	targetPath := filepath.Join(toolPath, targetName)
+	targetPath := filepath.Join(stagingPath, targetName)
 
 	if err := fileio.CopyFile(source, targetPath, false); err != nil {
-		return "", err
+		return "", errors.Join(err, os.RemoveAll(stagingPath))
 	}
 
 	if err := ensureExecutable(targetPath); err != nil {
-		return "", err
+		return "", errors.Join(err, os.RemoveAll(stagingPath))
 	}
 
-	markerPath := getMarkerPath(toolPath)
-	marker, err := os.Create(markerPath)
-	if err != nil {
-		return "", err
+	if metadata.Source == (MetadataSource{}) {
+		metadata.Source.Path, _ = filepath.Abs(source)
+	}
+
+	if metadata.SHA256 == "" {
+		digest, err := fileio.SHA256File(source)
+		if err != nil {
+			return "", errors.Join(err, os.RemoveAll(stagingPath))
+		}
+		metadata.SHA256 = digest
+	}
+
+	if err := publishToolPath(stagingPath, toolPath, completeMetadata(metadata, tool, version, nodeArch)); err != nil {
+		return "", errors.Join(err, os.RemoveAll(stagingPath))
 	}
-	marker.Close()
 
 	return toolPath, nil
 }
 
-// createToolPath creates a path to a specific version and architecture of a tool in the GitHub Actions runner tool cache.
-func createToolPath(tool, version, arch string) (string, error) {
+// stageToolDir stages a tool dir in an empty staging directory.
+// If move is set, the source is moved with a rename if it is on the same filesystem and has no absolute symlinks,
+// otherwise the source is copied.
+func stageToolDir(source, stagingPath string, move bool) error {
+	if err := fileio.CheckSymlinks(source); err != nil {
+		return err
+	}
+
+	// Absolute symlinks would point back into the source once it's moved, so they're made relative by copying instead.
+	if move {
+		abs, err := fileio.HasAbsoluteSymlinks(source)
+		if err != nil {
+			return err
+		}
+		move = !abs
+	}
+
+	if move {
+		if err := os.Remove(stagingPath); err != nil {
+			return err
+		}
+
+		if err := os.Rename(source, stagingPath); err == nil {
+			return os.Chmod(stagingPath, 0o755)
+		}
+
+		if err := os.Mkdir(stagingPath, 0o755); err != nil {
+			return err
+		}
+	}
+
+	return fileio.CopyDir(source, stagingPath)
+}
+
+// createStagingPath returns the path to a specific version and architecture of a tool in the GitHub Actions runner tool cache,
+// along with a newly created hidden sibling directory to stage the tool in before it is published.
+func createStagingPath(tool, version, arch string) (string, string, error) {
 	cacheDir, err := GetToolCacheDirectory()
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	if tool == "" {
-		return "", fmt.Errorf("tool is not defined")
+		return "", "", fmt.Errorf("tool is not defined")
 	}
 
 	if version == "" {
-		return "", fmt.Errorf("version is not defined")
+		return "", "", fmt.Errorf("version is not defined")
 	}
 
 	if arch == "" {
-		return "", fmt.Errorf("arch is not defined")
+		return "", "", fmt.Errorf("arch is not defined")
 	}
 
 	toolPath := filepath.Join(cacheDir, tool, version, arch)
-	markerPath := getMarkerPath(toolPath)
+	versionPath := filepath.Dir(toolPath)
 
-	if err := os.RemoveAll(toolPath); err != nil {
-		return "", err
+	if err := os.MkdirAll(versionPath, 0o755); err != nil {
+		return "", "", err
 	}
 
+	stagingPath, err := os.MkdirTemp(versionPath, "."+arch+"-*")
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := os.Chmod(stagingPath, 0o755); err != nil {
+		return "", "", errors.Join(err, os.RemoveAll(stagingPath))
+	}
+
+	return toolPath, stagingPath, nil
+}
+
+// publishToolPath atomically moves a staged tool to its final path and writes its metadata, hash tree and marker.
+// Any existing tool at the path is invalidated by removing its marker and then replaced.
+func publishToolPath(stagingPath, toolPath string, metadata Metadata) error {
+	markerPath := getMarkerPath(toolPath)
+
 	if err := os.Remove(markerPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
-		return "", err
+		return err
 	}
 
-	if err := os.MkdirAll(toolPath, 0o755); err != nil {
-		return "", err
+	for _, p := range []string{getMetadataPath(toolPath), getHashTreePath(toolPath)} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
 	}
 
-	return toolPath, nil
+	hashes, err := hashTree(stagingPath)
+	if err != nil {
+		return err
+	}
+
+	exists, err := fileio.DirExists(toolPath)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		replacedPath := stagingPath + "-replaced"
+		if err := os.Rename(toolPath, replacedPath); err != nil {
+			return err
+		}
+		defer os.RemoveAll(replacedPath)
+	}
+
+	if err := os.Rename(stagingPath, toolPath); err != nil {
+		return err
+	}
+
+	if err := writeMetadata(toolPath, metadata); err != nil {
+		return err
+	}
+
+	if err := writeHashTree(getHashTreePath(toolPath), hashes); err != nil {
+		return err
+	}
+
+	marker, err := os.Create(markerPath)
+	if err != nil {
+		return err
+	}
+
+	return marker.Close()
 }
 
 // getMarkerPath returns the path to the marker file for a specific version and architecture of a tool in the GitHub Actions runner tool cache.
@@ -270,14 +493,83 @@ func getMarkerPath(toolPath string) string {
 	return fmt.Sprintf("%s.complete", toolPath)
 }
 
-// getNodeArch returns the architecture in Node.js format.
-func getNodeArch(arch string) string {
+// archFallbacks are the architectures that can run tools built for another architecture, keyed by OS and Node.js architecture.
+var archFallbacks = map[string]map[string][]string{
+	// Rosetta 2 runs x64 tools on Apple silicon.
+	"darwin": {
+		"arm64": {"x64"},
+	},
+	// WOW64 runs ia32 tools on x64, and Windows on Arm emulates both x64 and ia32.
+	"windows": {
+		"x64":   {"ia32"},
+		"arm64": {"x64", "ia32"},
+	},
+}
+
+// NodeArch returns the architecture in Node.js format, as used by the runner images and setup-* actions.
+func NodeArch(arch string) string {
 	switch arch {
 	case "amd64":
 		return "x64"
 	case "386":
 		return "ia32"
+	case "arm":
+		return "arm"
+	case "ppc64", "ppc64le":
+		return "ppc64"
+	case "s390x":
+		return "s390x"
+	case "riscv64":
+		return "riscv64"
 	default:
 		return arch
 	}
 }
+
+// platformArchSeparator separates the OS from the architecture in the architecture segment of a tool built for another OS.
+const platformArchSeparator = "-"
+
+// PlatformArch returns the architecture segment of the GitHub Actions runner tool cache layout for a tool built for an OS.
+// Tools for the host OS use the Node.js architecture, as the runner does, while tools for another OS are qualified
+// with the OS, such as darwin-arm64, so they don't replace the host tools.
+// The architecture may already be qualified with an OS; if osName is not empty, it takes precedence.
+func PlatformArch(osName, arch string) string {
+	archOS, nodeArch := splitPlatformArch(arch)
+	if osName == "" {
+		osName = archOS
+	}
+
+	if osName == "" || osName == runtime.GOOS {
+		return nodeArch
+	}
+
+	return osName + platformArchSeparator + nodeArch
+}
+
+// splitPlatformArch splits an architecture segment into its OS, which is empty for host tools, and its Node.js architecture.
+func splitPlatformArch(segment string) (string, string) {
+	osName, arch, ok := strings.Cut(segment, platformArchSeparator)
+	if !ok {
+		return "", NodeArch(segment)
+	}
+
+	return osName, NodeArch(arch)
+}
+
+// DefaultArchFallbacks returns the architectures, in order of preference, that can run tools for an architecture on an OS.
+// It returns nil if there is no fallback.
+func DefaultArchFallbacks(osName, arch string) []string {
+	return archFallbacks[osName][NodeArch(arch)]
+}
+
+// getArchChain returns the architecture segments to search for a tool built for an OS, starting with the requested architecture.
+func getArchChain(osName, arch string, fallbacks []string) []string {
+	chain := []string{PlatformArch(osName, arch)}
+	for _, fallback := range fallbacks {
+		a := PlatformArch(osName, fallback)
+		if !slices.Contains(chain, a) {
+			chain = append(chain, a)
+		}
+	}
+	return chain
+}
@@ -1,7 +1,9 @@
 package toolcache
 
 import (
+	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/matryer/is"
@@ -19,8 +21,8 @@ func TestGetToolCacheDirectory(t *testing.T) {
 		wantErr bool
 	}{
 		{
-			name:    "errors_if_tool_cache_dir_env_variable_is_not_defined",
-			tc:      "",
+			name:    "errors_if_the_tool_cache_dir_does_not_exist",
+			tc:      "../../../testdata/non-existent-tool-cache",
 			wantErr: true,
 		},
 		{
@@ -48,6 +50,85 @@ func TestGetToolCacheDirectory(t *testing.T) {
 	}
 }
 
+func TestLookupToolCacheDirectory(t *testing.T) {
+	runnerDir := t.TempDir()
+	ghactlDir := filepath.Join(t.TempDir(), "ghactl")
+	xdgDir := t.TempDir()
+	homeDir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		runner     string
+		ghactl     string
+		xdg        string
+		want       string
+		wantSource string
+		wantErr    bool
+	}{
+		{
+			name:    "errors_if_the_runner_tool_cache_dir_does_not_exist",
+			runner:  filepath.Join(runnerDir, "non-existent"),
+			ghactl:  ghactlDir,
+			wantErr: true,
+		},
+		{
+			name:       "prefers_the_runner_tool_cache_dir",
+			runner:     runnerDir,
+			ghactl:     ghactlDir,
+			xdg:        xdgDir,
+			want:       runnerDir,
+			wantSource: "RUNNER_TOOL_CACHE",
+		},
+		{
+			name:       "uses_the_ghactl_tool_cache_dir_outside_of_actions",
+			ghactl:     ghactlDir,
+			xdg:        xdgDir,
+			want:       ghactlDir,
+			wantSource: "GHACTL_TOOL_CACHE",
+		},
+		{
+			name:       "uses_the_xdg_cache_dir",
+			xdg:        xdgDir,
+			want:       filepath.Join(xdgDir, "ghactl", "tools"),
+			wantSource: "XDG_CACHE_HOME",
+		},
+		{
+			name:    "errors_if_the_xdg_cache_dir_is_relative",
+			xdg:     "relative",
+			wantErr: true,
+		},
+		{
+			name:       "uses_the_user_cache_dir",
+			want:       filepath.Join(homeDir, ".cache", "ghactl", "tools"),
+			wantSource: "default",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			t.Setenv(runnerToolCacheLookup, tt.runner)
+			t.Setenv(ghactlToolCacheLookup, tt.ghactl)
+			t.Setenv(xdgCacheHomeLookup, tt.xdg)
+			t.Setenv("HOME", homeDir)
+
+			result, source, err := LookupToolCacheDirectory()
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err)                   // should not error
+			is.Equal(result, tt.want)       // should match
+			is.Equal(source, tt.wantSource) // should report the source
+
+			exists, _ := fileio.DirExists(result)
+			is.True(exists) // should create the dir
+		})
+	}
+}
+
 func TestFindAllToolVersions(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -58,8 +139,8 @@ func TestFindAllToolVersions(t *testing.T) {
 		wantErr bool
 	}{
 		{
-			name:    "errors_if_tool_cache_dir_env_variable_is_not_defined",
-			tc:      "",
+			name:    "errors_if_the_tool_cache_dir_does_not_exist",
+			tc:      "../../../testdata/non-existent-tool-cache",
 			tool:    "test-tool",
 			arch:    "amd64",
 			wantErr: true,
@@ -106,7 +187,7 @@ func TestFindAllToolVersions(t *testing.T) {
 			is := is.New(t)
 			t.Setenv(runnerToolCacheLookup, tt.tc)
 
-			result, err := FindAllToolVersions(tt.tool, tt.arch)
+			result, err := FindAllToolVersions(tt.tool, tt.arch, "", FindOptions{})
 
 			if tt.wantErr {
 				is.True(err != nil) // should error
@@ -132,8 +213,8 @@ func TestFindTool(t *testing.T) {
 		wantErr     bool
 	}{
 		{
-			name:        "errors_if_tool_cache_dir_env_variable_is_not_defined",
-			tc:          "",
+			name:        "errors_if_the_tool_cache_dir_does_not_exist",
+			tc:          "../../../testdata/non-existent-tool-cache",
 			tool:        "test-tool",
 			arch:        "amd64",
 			versionSpec: "1.0.0",
@@ -185,7 +266,7 @@ func TestFindTool(t *testing.T) {
 			tool:        "test-tool",
 			arch:        "amd64",
 			versionSpec: "1.0.0",
-			want:        filepath.Join(tc, "test-tool", "1.0.0", getNodeArch("amd64")),
+			want:        filepath.Join(tc, "test-tool", "1.0.0", NodeArch("amd64")),
 		},
 		{
 			name:        "returns_patch_version_match",
@@ -193,7 +274,7 @@ func TestFindTool(t *testing.T) {
 			tool:        "test-tool",
 			arch:        "amd64",
 			versionSpec: "~1.0.0",
-			want:        filepath.Join(tc, "test-tool", "1.0.1", getNodeArch("amd64")),
+			want:        filepath.Join(tc, "test-tool", "1.0.1", NodeArch("amd64")),
 		},
 		{
 			name:        "returns_minor_version_match",
@@ -201,7 +282,7 @@ func TestFindTool(t *testing.T) {
 			tool:        "test-tool",
 			arch:        "amd64",
 			versionSpec: "^1.0.0",
-			want:        filepath.Join(tc, "test-tool", "1.2.0", getNodeArch("amd64")),
+			want:        filepath.Join(tc, "test-tool", "1.2.0", NodeArch("amd64")),
 		},
 		{
 			name:        "returns_major_version_match",
@@ -209,7 +290,7 @@ func TestFindTool(t *testing.T) {
 			tool:        "test-tool",
 			arch:        "amd64",
 			versionSpec: ">=1.0.0",
-			want:        filepath.Join(tc, "test-tool", "2.0.0", getNodeArch("amd64")),
+			want:        filepath.Join(tc, "test-tool", "2.0.0", NodeArch("amd64")),
 		},
 		{
 			name:        "returns_version_match_ignoring_v_prefix",
@@ -217,7 +298,7 @@ func TestFindTool(t *testing.T) {
 			tool:        "test-tool",
 			arch:        "amd64",
 			versionSpec: "v1.0.0",
-			want:        filepath.Join(tc, "test-tool", "1.0.0", getNodeArch("amd64")),
+			want:        filepath.Join(tc, "test-tool", "1.0.0", NodeArch("amd64")),
 		},
 		{
 			name:        "returns_version_match_with_wildcard",
@@ -225,7 +306,7 @@ func TestFindTool(t *testing.T) {
 			tool:        "test-tool",
 			arch:        "amd64",
 			versionSpec: "*",
-			want:        filepath.Join(tc, "test-tool", "2.0.0", getNodeArch("amd64")),
+			want:        filepath.Join(tc, "test-tool", "2.0.0", NodeArch("amd64")),
 		},
 	}
 
@@ -234,7 +315,7 @@ func TestFindTool(t *testing.T) {
 			is := is.New(t)
 			t.Setenv(runnerToolCacheLookup, tt.tc)
 
-			tp, err := FindTool(tt.tool, tt.arch, tt.versionSpec)
+			tp, err := FindTool(tt.tool, tt.arch, tt.versionSpec, FindOptions{})
 
 			if tt.wantErr {
 				is.True(err != nil) // should error
@@ -247,6 +328,408 @@ func TestFindTool(t *testing.T) {
 	}
 }
 
+func TestFindAllToolVersions_versionSpec(t *testing.T) {
+	is := is.New(t)
+	t.Setenv(runnerToolCacheLookup, "../../../testdata/tool-cache")
+
+	result, err := FindAllToolVersions("test-tool", "amd64", "^1.0.0", FindOptions{})
+
+	is.NoErr(err)                                         // should not error
+	is.Equal(result, []string{"1.0.0", "1.0.1", "1.2.0"}) // should only return matching versions
+}
+
+func TestFindTool_looseVersions(t *testing.T) {
+	tests := []struct {
+		name        string
+		versionSpec string
+		strict      bool
+		want        string
+		wantAll     []string
+	}{
+		{
+			name:        "finds_loose_versions",
+			versionSpec: "*",
+			want:        "2024.05.01",
+			wantAll:     []string{"1.2.0", "v1.10.0", "1.22", "2024.05.01"},
+		},
+		{
+			name:        "matches_loose_versions_by_semver",
+			versionSpec: "~1.22.0",
+			want:        "1.22",
+			wantAll:     []string{"1.22"},
+		},
+		{
+			name:        "sorts_loose_versions_by_semver",
+			versionSpec: "<1.20.0",
+			want:        "v1.10.0",
+			wantAll:     []string{"1.2.0", "v1.10.0"},
+		},
+		{
+			name:        "ignores_loose_versions_when_strict",
+			versionSpec: "*",
+			strict:      true,
+			want:        "1.2.0",
+			wantAll:     []string{"1.2.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			tc := t.TempDir()
+			t.Setenv(runnerToolCacheLookup, tc)
+
+			for _, v := range []string{"1.2.0", "v1.10.0", "1.22", "2024.05.01", "latest"} {
+				mustCreateTestCacheEntry(t, tc, "tool", v, "x64", true)
+			}
+
+			tp, err := FindTool("tool", "amd64", tt.versionSpec, FindOptions{Strict: tt.strict})
+
+			is.NoErr(err)                                           // should not error
+			is.Equal(tp, filepath.Join(tc, "tool", tt.want, "x64")) // should find the version directory
+
+			all, err := FindAllToolVersions("tool", "amd64", tt.versionSpec, FindOptions{Strict: tt.strict})
+
+			is.NoErr(err)             // should not error
+			is.Equal(all, tt.wantAll) // should return sorted version directories
+		})
+	}
+}
+
+func TestFindTool_archFallbacks(t *testing.T) {
+	tests := []struct {
+		name        string
+		arch        string
+		fallbacks   []string
+		cached      map[string]string
+		versionSpec string
+		want        string
+		wantAll     []string
+	}{
+		{
+			name:        "prefers_requested_arch",
+			arch:        "arm64",
+			fallbacks:   []string{"x64"},
+			cached:      map[string]string{"1.0.0": "arm64", "2.0.0": "x64"},
+			versionSpec: "*",
+			want:        filepath.Join("1.0.0", "arm64"),
+			wantAll:     []string{"1.0.0"},
+		},
+		{
+			name:        "falls_back_when_requested_arch_has_no_match",
+			arch:        "arm64",
+			fallbacks:   []string{"x64"},
+			cached:      map[string]string{"1.0.0": "arm64", "2.0.0": "x64"},
+			versionSpec: ">=2.0.0",
+			want:        filepath.Join("2.0.0", "x64"),
+			wantAll:     []string{"2.0.0"},
+		},
+		{
+			name:        "follows_fallback_order",
+			arch:        "arm64",
+			fallbacks:   []string{"amd64", "386"},
+			cached:      map[string]string{"1.0.0": "ia32", "2.0.0": "x64"},
+			versionSpec: "*",
+			want:        filepath.Join("2.0.0", "x64"),
+			wantAll:     []string{"2.0.0"},
+		},
+		{
+			name:        "does_not_fall_back_without_fallbacks",
+			arch:        "arm64",
+			cached:      map[string]string{"1.0.0": "x64"},
+			versionSpec: "*",
+			want:        "",
+			wantAll:     []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			tc := t.TempDir()
+			t.Setenv(runnerToolCacheLookup, tc)
+
+			for v, a := range tt.cached {
+				mustCreateTestCacheEntry(t, tc, "tool", v, a, true)
+			}
+
+			options := FindOptions{ArchFallbacks: tt.fallbacks}
+
+			tp, err := FindTool("tool", tt.arch, tt.versionSpec, options)
+
+			is.NoErr(err) // should not error
+			if tt.want == "" {
+				is.Equal(tp, "") // should not find the tool
+			} else {
+				is.Equal(tp, filepath.Join(tc, "tool", tt.want)) // should find the tool for the expected arch
+			}
+
+			all, err := FindAllToolVersions("tool", tt.arch, tt.versionSpec, options)
+
+			is.NoErr(err)             // should not error
+			is.Equal(all, tt.wantAll) // should return the versions for the selected arch
+		})
+	}
+}
+
+func TestFindTool_os(t *testing.T) {
+	is := is.New(t)
+	tc := t.TempDir()
+	t.Setenv(runnerToolCacheLookup, tc)
+
+	mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "x64", true)
+	mustCreateTestCacheEntry(t, tc, "tool", "2.0.0", otherOS()+"-x64", true)
+
+	tp, err := FindTool("tool", "amd64", "*", FindOptions{})
+
+	is.NoErr(err)                                           // should not error
+	is.Equal(tp, filepath.Join(tc, "tool", "1.0.0", "x64")) // should find the host tool
+
+	tp, err = FindTool("tool", "amd64", "*", FindOptions{OS: otherOS()})
+
+	is.NoErr(err)                                                      // should not error
+	is.Equal(tp, filepath.Join(tc, "tool", "2.0.0", otherOS()+"-x64")) // should find the tool for the OS
+
+	all, err := FindAllToolVersions("tool", "amd64", "*", FindOptions{OS: otherOS()})
+
+	is.NoErr(err)                    // should not error
+	is.Equal(all, []string{"2.0.0"}) // should only return the versions for the OS
+
+	tp, err = FindTool("tool", "amd64", "*", FindOptions{OS: runtime.GOOS})
+
+	is.NoErr(err)                                           // should not error
+	is.Equal(tp, filepath.Join(tc, "tool", "1.0.0", "x64")) // should find the host tool for the host OS
+}
+
+func TestCacheDir_os(t *testing.T) {
+	is := is.New(t)
+	tc := t.TempDir()
+	t.Setenv(runnerToolCacheLookup, tc)
+
+	source := t.TempDir()
+	mustCreateTestFile(t, filepath.Join(source, "tool"), "binary")
+
+	hostPath, err := CacheDir(source, "tool", "1.0.0", "amd64", Metadata{}, CacheDirOptions{})
+	is.NoErr(err) // should cache host tool
+
+	otherPath, err := CacheDir(source, "tool", "1.0.0", "amd64", Metadata{OS: otherOS()}, CacheDirOptions{})
+
+	is.NoErr(err)                                                             // should not error
+	is.Equal(hostPath, filepath.Join(tc, "tool", "1.0.0", "x64"))             // should cache host tool in the runner layout
+	is.Equal(otherPath, filepath.Join(tc, "tool", "1.0.0", otherOS()+"-x64")) // should cache tool for the OS next to it
+
+	metadata, err := GetMetadata("tool", otherOS()+"-amd64", "1.0.0", FindOptions{})
+	is.NoErr(err)                    // should read metadata
+	is.Equal(metadata.OS, otherOS()) // should record the OS
+	is.Equal(metadata.Arch, "x64")   // should record the unqualified arch
+
+	qualifiedPath, err := CacheFile(filepath.Join(source, "tool"), "tool", "tool", "2.0.0", otherOS()+"-arm64", Metadata{})
+	is.NoErr(err)                                                                   // should cache a file for a qualified arch
+	is.Equal(qualifiedPath, filepath.Join(tc, "tool", "2.0.0", otherOS()+"-arm64")) // should use the qualified arch
+
+	entries, err := ListCacheEntries("tool")
+	is.NoErr(err) // should list entries
+
+	oses := map[string]string{}
+	for _, e := range entries {
+		oses[e.Version+"/"+e.Arch] = e.OS
+	}
+	is.Equal(oses, map[string]string{
+		"1.0.0/x64":                     "",
+		"1.0.0/" + otherOS() + "-x64":   otherOS(),
+		"2.0.0/" + otherOS() + "-arm64": otherOS(),
+	}) // should list entries with their OS
+}
+
+func TestFindTool_prereleases(t *testing.T) {
+	tests := []struct {
+		name              string
+		cached            []string
+		versionSpec       string
+		includePrerelease bool
+		prefer            VersionPreference
+		want              string
+		wantAll           []string
+		wantErr           bool
+	}{
+		{
+			name:        "errors_if_preference_is_not_supported",
+			cached:      []string{"1.0.0"},
+			versionSpec: "*",
+			prefer:      "oldest",
+			wantErr:     true,
+		},
+		{
+			name:        "excludes_prereleases_by_default",
+			cached:      []string{"1.0.0", "1.1.0-rc.1"},
+			versionSpec: "^1.0.0",
+			want:        "1.0.0",
+			wantAll:     []string{"1.0.0"},
+		},
+		{
+			name:        "matches_prerelease_constraints",
+			cached:      []string{"1.0.0", "1.1.0-rc.1"},
+			versionSpec: "1.1.0-rc.1",
+			want:        "1.1.0-rc.1",
+			wantAll:     []string{"1.1.0-rc.1"},
+		},
+		{
+			name:              "includes_prereleases",
+			cached:            []string{"1.0.0", "1.1.0-rc.1"},
+			versionSpec:       "^1.0.0",
+			includePrerelease: true,
+			want:              "1.1.0-rc.1",
+			wantAll:           []string{"1.0.0", "1.1.0-rc.1"},
+		},
+		{
+			name:              "prefers_newest",
+			cached:            []string{"1.0.0", "1.1.0-rc.1"},
+			versionSpec:       "^1.0.0",
+			includePrerelease: true,
+			prefer:            PreferNewest,
+			want:              "1.1.0-rc.1",
+			wantAll:           []string{"1.0.0", "1.1.0-rc.1"},
+		},
+		{
+			name:              "prefers_stable",
+			cached:            []string{"1.0.0", "1.1.0-rc.1"},
+			versionSpec:       "^1.0.0",
+			includePrerelease: true,
+			prefer:            PreferStable,
+			want:              "1.0.0",
+			wantAll:           []string{"1.0.0", "1.1.0-rc.1"},
+		},
+		{
+			name:              "falls_back_to_prerelease_when_preferring_stable",
+			cached:            []string{"1.1.0-beta.1", "1.1.0-rc.1"},
+			versionSpec:       "^1.0.0",
+			includePrerelease: true,
+			prefer:            PreferStable,
+			want:              "1.1.0-rc.1",
+			wantAll:           []string{"1.1.0-beta.1", "1.1.0-rc.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			tc := t.TempDir()
+			t.Setenv(runnerToolCacheLookup, tc)
+
+			for _, v := range tt.cached {
+				mustCreateTestCacheEntry(t, tc, "tool", v, "x64", true)
+			}
+
+			options := FindOptions{IncludePrerelease: tt.includePrerelease, Prefer: tt.prefer}
+
+			tp, err := FindTool("tool", "amd64", tt.versionSpec, options)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err)                                           // should not error
+			is.Equal(tp, filepath.Join(tc, "tool", tt.want, "x64")) // should find the preferred version
+
+			all, err := FindAllToolVersions("tool", "amd64", tt.versionSpec, options)
+
+			is.NoErr(err)             // should not error
+			is.Equal(all, tt.wantAll) // should return the matching versions
+		})
+	}
+}
+
+func TestParseVersionPreference(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    VersionPreference
+		wantErr bool
+	}{
+		{
+			name: "defaults_to_newest",
+			s:    "",
+			want: PreferNewest,
+		},
+		{
+			name: "parses_newest",
+			s:    "newest",
+			want: PreferNewest,
+		},
+		{
+			name: "parses_stable",
+			s:    "stable",
+			want: PreferStable,
+		},
+		{
+			name:    "errors_if_preference_is_not_supported",
+			s:       "oldest",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			got, err := ParseVersionPreference(tt.s)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err)          // should not error
+			is.Equal(got, tt.want) // should return the preference
+		})
+	}
+}
+
+func TestDefaultArchFallbacks(t *testing.T) {
+	tests := []struct {
+		name   string
+		osName string
+		arch   string
+		want   []string
+	}{
+		{
+			name:   "returns_rosetta_fallback_on_darwin_arm64",
+			osName: "darwin",
+			arch:   "arm64",
+			want:   []string{"x64"},
+		},
+		{
+			name:   "returns_wow64_fallback_on_windows_amd64",
+			osName: "windows",
+			arch:   "amd64",
+			want:   []string{"ia32"},
+		},
+		{
+			name:   "returns_emulation_fallbacks_on_windows_arm64",
+			osName: "windows",
+			arch:   "arm64",
+			want:   []string{"x64", "ia32"},
+		},
+		{
+			name:   "returns_no_fallback_on_linux",
+			osName: "linux",
+			arch:   "amd64",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			fallbacks := DefaultArchFallbacks(tt.osName, tt.arch)
+
+			is.Equal(fallbacks, tt.want) // should match expected
+		})
+	}
+}
+
 func TestCacheDir(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -287,8 +770,8 @@ func TestCacheDir(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name:    "errors_if_the_tool_cache_path_is_not_defined",
-			tc:      "",
+			name:    "errors_if_the_tool_cache_dir_does_not_exist",
+			tc:      "../../../testdata/non-existent-tool-cache",
 			source:  "non-existent-path",
 			tool:    "test-tool",
 			version: "1.0.0",
@@ -309,7 +792,7 @@ func TestCacheDir(t *testing.T) {
 			is := is.New(t)
 
 			tc := tt.tc
-			if tc == "" && !tt.wantErr {
+			if tc == "" {
 				tc = t.TempDir()
 			}
 			t.Setenv(runnerToolCacheLookup, tc)
@@ -319,15 +802,15 @@ func TestCacheDir(t *testing.T) {
 				source = t.TempDir()
 			}
 
-			p, err := CacheDir(source, tt.tool, tt.version, tt.arch)
+			p, err := CacheDir(source, tt.tool, tt.version, tt.arch, Metadata{}, CacheDirOptions{})
 
 			if tt.wantErr {
 				is.True(err != nil) // should error
 				return
 			}
 
-			is.NoErr(err)                                                             // should not error
-			is.Equal(p, filepath.Join(tc, tt.tool, tt.version, getNodeArch(tt.arch))) // should be equal
+			is.NoErr(err)                                                          // should not error
+			is.Equal(p, filepath.Join(tc, tt.tool, tt.version, NodeArch(tt.arch))) // should be equal
 
 			toolExists, _ := fileio.FileExists(filepath.Join(p, "test-tool"))
 			markerExists, _ := fileio.FileExists(getMarkerPath(p))
@@ -338,6 +821,120 @@ func TestCacheDir(t *testing.T) {
 	}
 }
 
+func TestCacheDir_symlinksAndModes(t *testing.T) {
+	t.Run("preserves_symlinks_and_modes", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, tc)
+
+		source := t.TempDir()
+		mustCreateTestDir(t, filepath.Join(source, "bin"))
+		mustCreateTestDir(t, filepath.Join(source, "lib"))
+		mustCreateTestFile(t, filepath.Join(source, "lib", "tool.js"), "script")
+		is.NoErr(os.Chmod(filepath.Join(source, "lib", "tool.js"), 0o750))                                // should set mode
+		is.NoErr(os.Symlink(filepath.Join("..", "lib", "tool.js"), filepath.Join(source, "bin", "tool"))) // should create symlink
+
+		p, err := CacheDir(source, "tool", "1.0.0", "amd64", Metadata{}, CacheDirOptions{})
+
+		is.NoErr(err) // should not error
+
+		link, err := os.Readlink(filepath.Join(p, "bin", "tool"))
+		is.NoErr(err)                                         // should keep symlink
+		is.Equal(link, filepath.Join("..", "lib", "tool.js")) // should keep relative target
+
+		fi, err := os.Stat(filepath.Join(p, "lib", "tool.js"))
+		is.NoErr(err)                                  // should stat file
+		is.Equal(fi.Mode().Perm(), os.FileMode(0o750)) // should keep mode
+
+		sourceExists, _ := fileio.DirExists(source)
+		is.True(sourceExists) // should copy a source that isn't a temporary extraction
+	})
+
+	t.Run("errors_if_a_symlink_escapes_the_source", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, tc)
+
+		source := t.TempDir()
+		is.NoErr(os.Symlink(filepath.Join("..", "outside"), filepath.Join(source, "escape"))) // should create symlink
+
+		_, err := CacheDir(source, "tool", "1.0.0", "amd64", Metadata{}, CacheDirOptions{})
+
+		is.True(err != nil) // should error
+
+		items, _ := os.ReadDir(filepath.Join(tc, "tool", "1.0.0"))
+		is.Equal(len(items), 0) // should remove the staging dir
+	})
+
+	t.Run("copies_temporary_extractions_without_move", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, tc)
+		t.Setenv("RUNNER_TEMP", t.TempDir())
+
+		source, err := createExtractDir()
+		is.NoErr(err) // should create extract dir
+		mustCreateTestFile(t, filepath.Join(source, "tool"), "binary")
+
+		_, err = CacheDir(source, "tool", "1.0.0", "amd64", Metadata{}, CacheDirOptions{})
+
+		is.NoErr(err) // should not error
+
+		sourceExists, _ := fileio.DirExists(source)
+		is.True(sourceExists) // should keep the user's directory
+	})
+
+	t.Run("moves_source_with_move", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, tc)
+		t.Setenv("RUNNER_TEMP", t.TempDir())
+
+		source, err := createExtractDir()
+		is.NoErr(err) // should create extract dir
+		mustCreateTestFile(t, filepath.Join(source, "tool"), "binary")
+
+		p, err := CacheDir(source, "tool", "1.0.0", "amd64", Metadata{}, CacheDirOptions{Move: true})
+
+		is.NoErr(err) // should not error
+
+		data, err := os.ReadFile(filepath.Join(p, "tool"))
+		is.NoErr(err)                    // should read tool
+		is.Equal(string(data), "binary") // content should match
+
+		fi, err := os.Stat(p)
+		is.NoErr(err)                                  // should stat tool dir
+		is.Equal(fi.Mode().Perm(), os.FileMode(0o755)) // should make the tool dir readable
+
+		sourceExists, _ := fileio.DirExists(source)
+		is.True(!sourceExists) // should move the extraction
+	})
+
+	t.Run("makes_absolute_symlinks_relative_with_move", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, tc)
+		t.Setenv("RUNNER_TEMP", t.TempDir())
+
+		source, err := createExtractDir()
+		is.NoErr(err) // should create extract dir
+		mustCreateTestFile(t, filepath.Join(source, "tool"), "binary")
+		is.NoErr(os.Symlink(filepath.Join(source, "tool"), filepath.Join(source, "link"))) // should create symlink
+
+		p, err := CacheDir(source, "tool", "1.0.0", "amd64", Metadata{}, CacheDirOptions{Move: true})
+
+		is.NoErr(err) // should not error
+
+		link, err := os.Readlink(filepath.Join(p, "link"))
+		is.NoErr(err)          // should be a symlink
+		is.Equal(link, "tool") // should make the absolute target relative, as when copying
+
+		data, err := os.ReadFile(filepath.Join(p, "link"))
+		is.NoErr(err)                    // should resolve the symlink in the cache
+		is.Equal(string(data), "binary") // content should match
+	})
+}
+
 func TestCacheFile(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -391,8 +988,8 @@ func TestCacheFile(t *testing.T) {
 			wantErr:    true,
 		},
 		{
-			name:       "errors_if_the_tool_cache_path_is_not_defined",
-			tc:         "",
+			name:       "errors_if_the_tool_cache_dir_does_not_exist",
+			tc:         "../../../testdata/non-existent-tool-cache",
 			source:     "non-existent-path",
 			targetName: "test-tool",
 			tool:       "test-tool",
@@ -415,7 +1012,7 @@ func TestCacheFile(t *testing.T) {
 			is := is.New(t)
 
 			tc := tt.tc
-			if tc == "" && !tt.wantErr {
+			if tc == "" {
 				tc = t.TempDir()
 			}
 			t.Setenv(runnerToolCacheLookup, tc)
@@ -425,15 +1022,15 @@ func TestCacheFile(t *testing.T) {
 				source = t.TempDir()
 			}
 
-			p, err := CacheFile(source, tt.targetName, tt.tool, tt.version, tt.arch)
+			p, err := CacheFile(source, tt.targetName, tt.tool, tt.version, tt.arch, Metadata{})
 
 			if tt.wantErr {
 				is.True(err != nil) // should error
 				return
 			}
 
-			is.NoErr(err)                                                             // should not error
-			is.Equal(p, filepath.Join(tc, tt.tool, tt.version, getNodeArch(tt.arch))) // should be equal
+			is.NoErr(err)                                                          // should not error
+			is.Equal(p, filepath.Join(tc, tt.tool, tt.version, NodeArch(tt.arch))) // should be equal
 
 			toolExists, _ := fileio.FileExists(filepath.Join(p, tt.targetName))
 			markerExists, _ := fileio.FileExists(getMarkerPath(p))
@@ -444,7 +1041,7 @@ func TestCacheFile(t *testing.T) {
 	}
 }
 
-func Test_createToolPath(t *testing.T) {
+func Test_createStagingPath(t *testing.T) {
 	tests := []struct {
 		name    string
 		tc      string
@@ -454,8 +1051,8 @@ func Test_createToolPath(t *testing.T) {
 		wantErr bool
 	}{
 		{
-			name:    "errors_if_the_tool_cache_path_is_not_defined",
-			tc:      "UNSET",
+			name:    "errors_if_the_tool_cache_dir_does_not_exist",
+			tc:      "../../../testdata/non-existent-tool-cache",
 			tool:    "tool",
 			version: "1.0.0",
 			arch:    "amd64",
@@ -495,15 +1092,12 @@ func Test_createToolPath(t *testing.T) {
 			is := is.New(t)
 
 			tc := tt.tc
-			switch tc {
-			case "":
+			if tc == "" {
 				tc = t.TempDir()
-			case "UNSET":
-				tc = ""
 			}
 			t.Setenv(runnerToolCacheLookup, tc)
 
-			vtp, err := createToolPath(tt.tool, tt.version, tt.arch)
+			vtp, stp, err := createStagingPath(tt.tool, tt.version, tt.arch)
 
 			if tt.wantErr {
 				is.True(err != nil) // should error
@@ -512,16 +1106,68 @@ func Test_createToolPath(t *testing.T) {
 
 			is.NoErr(err)                                                  // should not error
 			is.Equal(vtp, filepath.Join(tc, tt.tool, tt.version, tt.arch)) // should be equal
+			is.Equal(filepath.Dir(stp), filepath.Dir(vtp))                 // staging dir should be a sibling
 
 			dirExists, _ := fileio.DirExists(vtp)
+			stagingExists, _ := fileio.DirExists(stp)
 			markerExists, _ := fileio.FileExists(getMarkerPath(vtp))
 
-			is.True(dirExists)     // dir should exist
+			is.True(!dirExists)    // dir should not exist until published
+			is.True(stagingExists) // staging dir should exist
 			is.True(!markerExists) // marker file should not exist
 		})
 	}
 }
 
+func Test_publishToolPath(t *testing.T) {
+	t.Run("publishes_staged_tool_with_marker", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, tc)
+
+		tp, stp, err := createStagingPath("tool", "1.0.0", "x64")
+		is.NoErr(err) // should not error
+		mustCreateTestFile(t, filepath.Join(stp, "tool"), "new")
+
+		err = publishToolPath(stp, tp, Metadata{})
+
+		is.NoErr(err) // should not error
+
+		stagingExists, _ := fileio.DirExists(stp)
+		markerExists, _ := fileio.FileExists(getMarkerPath(tp))
+		data, _ := os.ReadFile(filepath.Join(tp, "tool"))
+
+		is.True(!stagingExists)       // staging dir should be moved
+		is.True(markerExists)         // marker file should exist
+		is.Equal(string(data), "new") // tool should be published
+	})
+
+	t.Run("replaces_existing_tool", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, tc)
+
+		tp := mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "x64", true)
+		mustCreateTestFile(t, filepath.Join(tp, "stale"), "stale")
+
+		_, stp, err := createStagingPath("tool", "1.0.0", "x64")
+		is.NoErr(err) // should not error
+		mustCreateTestFile(t, filepath.Join(stp, "tool"), "new")
+
+		err = publishToolPath(stp, tp, Metadata{})
+
+		is.NoErr(err) // should not error
+
+		staleExists, _ := fileio.FileExists(filepath.Join(tp, "stale"))
+		items, _ := os.ReadDir(filepath.Dir(tp))
+		data, _ := os.ReadFile(filepath.Join(tp, "tool"))
+
+		is.True(!staleExists)         // old tool should be replaced
+		is.Equal(len(items), 4)       // should only leave the tool, its sidecars and its marker
+		is.Equal(string(data), "new") // tool should be published
+	})
+}
+
 func Test_getMarkerPath(t *testing.T) {
 	tests := []struct {
 		name string
@@ -546,7 +1192,7 @@ func Test_getMarkerPath(t *testing.T) {
 	}
 }
 
-func Test_getNodeArch(t *testing.T) {
+func TestNodeArch(t *testing.T) {
 	tests := []struct {
 		name string
 		arch string
@@ -567,15 +1213,88 @@ func Test_getNodeArch(t *testing.T) {
 			arch: "arm64",
 			want: "arm64",
 		},
+		{
+			name: "returns_correct_arm_value",
+			arch: "arm",
+			want: "arm",
+		},
+		{
+			name: "returns_correct_ppc64le_value",
+			arch: "ppc64le",
+			want: "ppc64",
+		},
+		{
+			name: "returns_correct_s390x_value",
+			arch: "s390x",
+			want: "s390x",
+		},
+		{
+			name: "returns_correct_riscv64_value",
+			arch: "riscv64",
+			want: "riscv64",
+		},
+		{
+			name: "passes_through_node_values",
+			arch: "x64",
+			want: "x64",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			is := is.New(t)
 
-			na := getNodeArch(tt.arch)
+			na := NodeArch(tt.arch)
 
 			is.Equal(na, tt.want) // should match expected
 		})
 	}
 }
+
+func TestPlatformArch(t *testing.T) {
+	tests := []struct {
+		name   string
+		osName string
+		arch   string
+		want   string
+	}{
+		{
+			name: "returns_node_arch_if_os_is_empty",
+			arch: "amd64",
+			want: "x64",
+		},
+		{
+			name:   "returns_node_arch_for_host_os",
+			osName: runtime.GOOS,
+			arch:   "amd64",
+			want:   "x64",
+		},
+		{
+			name:   "qualifies_arch_for_other_os",
+			osName: otherOS(),
+			arch:   "amd64",
+			want:   otherOS() + "-x64",
+		},
+		{
+			name: "keeps_qualified_arch",
+			arch: otherOS() + "-arm64",
+			want: otherOS() + "-arm64",
+		},
+		{
+			name:   "replaces_os_of_qualified_arch",
+			osName: runtime.GOOS,
+			arch:   otherOS() + "-amd64",
+			want:   "x64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			got := PlatformArch(tt.osName, tt.arch)
+
+			is.Equal(got, tt.want) // should match expected
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package toolcache
+
+import "sort"
+
+// ToolUsage is the disk usage of a tool in the GitHub Actions runner tool cache.
+type ToolUsage struct {
+	Tool    string       `json:"tool"`
+	Size    int64        `json:"size"`
+	Entries []EntryUsage `json:"entries"`
+}
+
+// EntryUsage is the disk usage of a single version and architecture of a tool in the GitHub Actions runner tool cache.
+// The size includes the entry's marker and sidecars.
+type EntryUsage struct {
+	Version  string `json:"version"`
+	Arch     string `json:"arch"`
+	Path     string `json:"path"`
+	Complete bool   `json:"complete"`
+	Size     int64  `json:"size"`
+}
+
+// GetDiskUsage returns the disk usage of the tools in the GitHub Actions runner tool cache.
+// If tool is not empty, only the usage of that tool is returned.
+// Tools and their entries are sorted by size, largest first, and then by name.
+func GetDiskUsage(tool string) ([]ToolUsage, error) {
+	entries, err := ListCacheEntries(tool)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := []ToolUsage{}
+	indexes := map[string]int{}
+
+	for _, e := range entries {
+		size, err := getEntrySize(e.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		i, ok := indexes[e.Tool]
+		if !ok {
+			i = len(usage)
+			indexes[e.Tool] = i
+			usage = append(usage, ToolUsage{Tool: e.Tool, Entries: []EntryUsage{}})
+		}
+
+		usage[i].Size += size
+		usage[i].Entries = append(usage[i].Entries, EntryUsage{
+			Version:  e.Version,
+			Arch:     e.Arch,
+			Path:     e.Path,
+			Complete: e.Complete,
+			Size:     size,
+		})
+	}
+
+	for _, u := range usage {
+		sort.SliceStable(u.Entries, func(i, j int) bool {
+			return u.Entries[i].Size > u.Entries[j].Size
+		})
+	}
+
+	sort.SliceStable(usage, func(i, j int) bool {
+		return usage[i].Size > usage[j].Size
+	})
+
+	return usage, nil
+}
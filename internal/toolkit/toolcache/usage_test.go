@@ -0,0 +1,80 @@
+package toolcache
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestGetDiskUsage(t *testing.T) {
+	tests := []struct {
+		name      string
+		tool      string
+		wantTools []string
+		wantSizes map[string]int64
+	}{
+		{
+			name:      "reports_all_tools_largest_first",
+			wantTools: []string{"tool-b", "tool-a"},
+			wantSizes: map[string]int64{"tool-a": 14, "tool-b": 106},
+		},
+		{
+			name:      "limits_report_to_a_tool",
+			tool:      "tool-a",
+			wantTools: []string{"tool-a"},
+			wantSizes: map[string]int64{"tool-a": 14},
+		},
+		{
+			name:      "reports_nothing_for_a_missing_tool",
+			tool:      "tool-c",
+			wantTools: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			tc := t.TempDir()
+			t.Setenv(runnerToolCacheLookup, tc)
+
+			mustCreateTestCacheEntry(t, tc, "tool-a", "1.0.0", "x64", true)
+			mustCreateTestFile(t, getMetadataPath(mustCreateTestCacheEntry(t, tc, "tool-a", "1.1.0", "x64", false)), "{}")
+			p := mustCreateTestCacheEntry(t, tc, "tool-b", "2.0.0", "x64", true)
+			mustCreateTestFile(t, filepath.Join(p, "data"), strings.Repeat("x", 100))
+
+			usage, err := GetDiskUsage(tt.tool)
+
+			is.NoErr(err) // should not error
+
+			tools := []string{}
+			for _, u := range usage {
+				tools = append(tools, u.Tool)
+				is.Equal(u.Size, tt.wantSizes[u.Tool]) // should sum the entry sizes
+			}
+			is.Equal(tools, tt.wantTools) // should report the tools
+		})
+	}
+
+	t.Run("sorts_entries_largest_first", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, tc)
+
+		mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "x64", true)
+		mustCreateTestCacheEntry(t, tc, "tool", "1.0.0", "arm64", false)
+		p := mustCreateTestCacheEntry(t, tc, "tool", "1.1.0", "x64", true)
+		mustCreateTestFile(t, filepath.Join(p, "data"), "data")
+
+		usage, err := GetDiskUsage("")
+
+		is.NoErr(err)           // should not error
+		is.Equal(len(usage), 1) // should report one tool
+		is.Equal(usage[0].Entries, []EntryUsage{
+			{Version: "1.1.0", Arch: "x64", Path: p, Complete: true, Size: 10},
+			{Version: "1.0.0", Arch: "arm64", Path: filepath.Join(tc, "tool", "1.0.0", "arm64"), Size: 6},
+			{Version: "1.0.0", Arch: "x64", Path: filepath.Join(tc, "tool", "1.0.0", "x64"), Complete: true, Size: 6},
+		}) // should sort entries by size and then by name
+	})
+}
@@ -0,0 +1,219 @@
+package toolcache
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/action-stars/ghactl/internal/fileio"
+)
+
+// VerifyResult is the result of verifying a tool cache entry against the hash tree recorded when it was cached.
+type VerifyResult struct {
+	CacheEntry
+	// Unverified is true if the entry has no recorded hash tree.
+	Unverified bool
+	// Modified are the files whose contents no longer match their recorded hash.
+	Modified []string
+	// Missing are the recorded files that no longer exist.
+	Missing []string
+	// Extra are the files that were not recorded.
+	Extra []string
+	// Invalidated is true if the entry's marker was removed because it failed verification.
+	Invalidated bool
+}
+
+// OK returns true if the entry was verified without any modified, missing or extra files.
+func (r VerifyResult) OK() bool {
+	return !r.Unverified && len(r.Modified) == 0 && len(r.Missing) == 0 && len(r.Extra) == 0
+}
+
+// VerifyToolCache re-hashes the complete entries in the GitHub Actions runner tool cache and compares them
+// with the hash trees recorded when they were cached.
+// If tool is not empty, only the entries for that tool are verified.
+// If fix is true, entries that fail verification are invalidated by removing their marker,
+// so the next install fetches them again.
+func VerifyToolCache(tool string, fix bool) ([]VerifyResult, error) {
+	entries, err := ListCacheEntries(tool)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []VerifyResult{}
+
+	for _, e := range entries {
+		if !e.Complete {
+			continue
+		}
+
+		result, err := verifyCacheEntry(e)
+		if err != nil {
+			return nil, err
+		}
+
+		if fix && !result.Unverified && !result.OK() {
+			if err := os.Remove(getMarkerPath(e.Path)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			result.Invalidated = true
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// verifyCacheEntry compares the files of a cache entry with its recorded hash tree.
+func verifyCacheEntry(e CacheEntry) (VerifyResult, error) {
+	result := VerifyResult{CacheEntry: e}
+
+	want, err := readHashTree(getHashTreePath(e.Path))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			result.Unverified = true
+			return result, nil
+		}
+		return VerifyResult{}, err
+	}
+
+	got, err := hashTree(e.Path)
+	if err != nil {
+		return VerifyResult{}, err
+	}
+
+	for name, digest := range want {
+		actual, ok := got[name]
+		switch {
+		case !ok:
+			result.Missing = append(result.Missing, name)
+		case actual != digest:
+			result.Modified = append(result.Modified, name)
+		}
+	}
+
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			result.Extra = append(result.Extra, name)
+		}
+	}
+
+	sort.Strings(result.Modified)
+	sort.Strings(result.Missing)
+	sort.Strings(result.Extra)
+
+	return result, nil
+}
+
+// symlinkDigestPrefix is hashed before the target of a symlink, so a symlink's digest never matches a file's.
+const symlinkDigestPrefix = "symlink:"
+
+// hashTree returns the SHA-256 digest of each regular file and symlink under root, keyed by its slash separated
+// relative path. A symlink's digest is of its target, so a retargeted symlink is reported as modified.
+func hashTree(root string) (map[string]string, error) {
+	hashes := map[string]string{}
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() && d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+
+		digest, err := hashTreeEntry(p, d)
+		if err != nil {
+			return err
+		}
+
+		hashes[filepath.ToSlash(rel)] = digest
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// hashTreeEntry returns the SHA-256 digest of a regular file, or of the target of a symlink.
+func hashTreeEntry(p string, d fs.DirEntry) (string, error) {
+	if d.Type().IsRegular() {
+		return fileio.SHA256File(p)
+	}
+
+	link, err := os.Readlink(p)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(symlinkDigestPrefix + filepath.ToSlash(link)))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// writeHashTree writes a hash tree in sha256sum format, sorted by path.
+func writeHashTree(p string, hashes map[string]string) error {
+	names := make([]string, 0, len(hashes))
+	for name := range hashes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", hashes[name], name)
+	}
+
+	return os.WriteFile(p, []byte(b.String()), 0o644)
+}
+
+// readHashTree reads a hash tree written by writeHashTree.
+func readHashTree(p string) (map[string]string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hashes := map[string]string{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		digest, name, ok := strings.Cut(line, "  ")
+		if !ok {
+			return nil, fmt.Errorf("invalid hash tree line: %s", line)
+		}
+
+		hashes[name] = digest
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// getHashTreePath returns the path to the hash tree sidecar for a specific version and architecture of a tool
+// in the GitHub Actions runner tool cache.
+func getHashTreePath(toolPath string) string {
+	return fmt.Sprintf("%s.sha256sum", toolPath)
+}
@@ -0,0 +1,158 @@
+package toolcache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/action-stars/ghactl/internal/fileio"
+)
+
+func TestVerifyToolCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(t *testing.T, p string)
+		fix      bool
+		want     VerifyResult
+		wantOK   bool
+		wantMark bool
+	}{
+		{
+			name:     "verifies_unmodified_entry",
+			tamper:   func(*testing.T, string) {},
+			wantOK:   true,
+			wantMark: true,
+		},
+		{
+			name: "reports_modified_files",
+			tamper: func(t *testing.T, p string) {
+				mustCreateTestFile(t, filepath.Join(p, "bin", "tool"), "tampered")
+			},
+			want:     VerifyResult{Modified: []string{"bin/tool"}},
+			wantMark: true,
+		},
+		{
+			name: "reports_missing_files",
+			tamper: func(t *testing.T, p string) {
+				if err := os.Remove(filepath.Join(p, "README")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want:     VerifyResult{Missing: []string{"README"}},
+			wantMark: true,
+		},
+		{
+			name: "reports_extra_files",
+			tamper: func(t *testing.T, p string) {
+				mustCreateTestFile(t, filepath.Join(p, "bin", "extra"), "extra")
+			},
+			want:     VerifyResult{Extra: []string{"bin/extra"}},
+			wantMark: true,
+		},
+		{
+			name: "reports_retargeted_symlinks",
+			tamper: func(t *testing.T, p string) {
+				if err := os.Remove(filepath.Join(p, "bin", "link")); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Symlink("../README", filepath.Join(p, "bin", "link")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want:     VerifyResult{Modified: []string{"bin/link"}},
+			wantMark: true,
+		},
+		{
+			name: "reports_extra_symlinks",
+			tamper: func(t *testing.T, p string) {
+				if err := os.Symlink("tool", filepath.Join(p, "bin", "extra")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want:     VerifyResult{Extra: []string{"bin/extra"}},
+			wantMark: true,
+		},
+		{
+			name: "invalidates_failed_entries_with_fix",
+			tamper: func(t *testing.T, p string) {
+				mustCreateTestFile(t, filepath.Join(p, "bin", "tool"), "tampered")
+			},
+			fix:  true,
+			want: VerifyResult{Modified: []string{"bin/tool"}, Invalidated: true},
+		},
+		{
+			name: "does_not_invalidate_entries_without_a_hash_tree",
+			tamper: func(t *testing.T, p string) {
+				if err := os.Remove(getHashTreePath(p)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			fix:      true,
+			want:     VerifyResult{Unverified: true},
+			wantMark: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			t.Setenv(runnerToolCacheLookup, t.TempDir())
+
+			source := t.TempDir()
+			mustCreateTestDir(t, filepath.Join(source, "bin"))
+			mustCreateTestFile(t, filepath.Join(source, "bin", "tool"), "binary")
+			mustCreateTestFile(t, filepath.Join(source, "README"), "readme")
+			if err := os.Symlink("tool", filepath.Join(source, "bin", "link")); err != nil {
+				t.Fatal(err)
+			}
+
+			p, err := CacheDir(source, "tool", "1.0.0", "amd64", Metadata{}, CacheDirOptions{})
+			is.NoErr(err) // should not error
+
+			tt.tamper(t, p)
+
+			results, err := VerifyToolCache("", tt.fix)
+
+			is.NoErr(err)             // should not error
+			is.Equal(len(results), 1) // should verify the entry
+
+			got := results[0]
+			is.Equal(got.OK(), tt.wantOK)                  // ok should match
+			is.Equal(got.Unverified, tt.want.Unverified)   // unverified should match
+			is.Equal(got.Modified, tt.want.Modified)       // modified files should match
+			is.Equal(got.Missing, tt.want.Missing)         // missing files should match
+			is.Equal(got.Extra, tt.want.Extra)             // extra files should match
+			is.Equal(got.Invalidated, tt.want.Invalidated) // invalidated should match
+
+			markerExists, _ := fileio.FileExists(getMarkerPath(p))
+			is.Equal(markerExists, tt.wantMark) // marker should only be removed by fix
+		})
+	}
+}
+
+func Test_readHashTree(t *testing.T) {
+	t.Run("reads_hash_tree_written_by_write_hash_tree", func(t *testing.T) {
+		is := is.New(t)
+		p := filepath.Join(t.TempDir(), "x64.sha256sum")
+		want := map[string]string{"bin/tool": "abc", "README": "def"}
+
+		is.NoErr(writeHashTree(p, want)) // should not error
+
+		got, err := readHashTree(p)
+
+		is.NoErr(err)       // should not error
+		is.Equal(got, want) // should match
+	})
+
+	t.Run("errors_on_invalid_line", func(t *testing.T) {
+		is := is.New(t)
+		p := filepath.Join(t.TempDir(), "x64.sha256sum")
+		mustCreateTestFile(t, p, "invalid\n")
+
+		_, err := readHashTree(p)
+
+		is.True(err != nil) // should error
+	})
+}
@@ -16,3 +16,26 @@ func CheckVersion(version, versionSpec string) (bool, error) {
 
 	return c.Check(v), nil
 }
+
+// ParseVersion parses a tool version.
+// If strict is false, a leading "v", missing minor or patch numbers and leading zeros are accepted,
+// so versions such as v1.2.3, 1.22 and 2024.05.01 written by other tooling can be found and sorted.
+// The original version string is kept and returned by Original.
+func ParseVersion(version string, strict bool) (*semver.Version, error) {
+	if strict {
+		return semver.StrictNewVersion(version)
+	}
+
+	return semver.NewVersion(version)
+}
+
+// NormalizeVersion returns the strict semver form of a tool version accepted by ParseVersion,
+// for example 1.2.3 for v1.2.3, 1.22.0 for 1.22 and 2024.5.1 for 2024.05.01.
+func NormalizeVersion(version string) (string, error) {
+	v, err := ParseVersion(version, false)
+	if err != nil {
+		return "", err
+	}
+
+	return v.String(), nil
+}
@@ -110,3 +110,74 @@ func TestCheckVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "errors_on_invalid_version",
+			version: "bad",
+			wantErr: true,
+		},
+		{
+			name:    "keeps_strict_version",
+			version: "1.2.3-rc.1",
+			want:    "1.2.3-rc.1",
+		},
+		{
+			name:    "removes_v_prefix",
+			version: "v1.2.3",
+			want:    "1.2.3",
+		},
+		{
+			name:    "adds_missing_patch_version",
+			version: "1.22",
+			want:    "1.22.0",
+		},
+		{
+			name:    "removes_leading_zeros",
+			version: "2024.05.01",
+			want:    "2024.5.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			got, err := NormalizeVersion(tt.version)
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err)          // should not error
+			is.Equal(got, tt.want) // should match
+		})
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	t.Run("errors_on_loose_version_when_strict", func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := ParseVersion("v1.2.3", true)
+
+		is.True(err != nil) // should error
+	})
+
+	t.Run("keeps_original_loose_version", func(t *testing.T) {
+		is := is.New(t)
+
+		v, err := ParseVersion("v1.2", false)
+
+		is.NoErr(err)                  // should not error
+		is.Equal(v.Original(), "v1.2") // should keep original
+		is.Equal(v.String(), "1.2.0")  // should normalize
+	})
+}
@@ -0,0 +1,105 @@
+package toolcache
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// windowsExecutableExt is the extension of executables in Windows layouts.
+const windowsExecutableExt = ".exe"
+
+// FindExecutable returns the path to an executable in a tool in the GitHub Actions runner tool cache
+// that matches the version constraint.
+// If bin is not empty, only executables with that name, with or without an .exe extension, are candidates.
+// Otherwise, if the tool has several executables, the one named after the tool is preferred.
+// It returns an error if the tool is not cached or if there isn't exactly one candidate.
+func FindExecutable(tool, arch, versionSpec, bin string, options FindOptions) (string, error) {
+	toolPath, err := FindTool(tool, arch, versionSpec, options)
+	if err != nil {
+		return "", err
+	}
+
+	if toolPath == "" {
+		return "", fmt.Errorf("tool %s %s is not cached", tool, versionSpec)
+	}
+
+	executables, err := listExecutables(toolPath)
+	if err != nil {
+		return "", err
+	}
+
+	candidates := executables
+	if bin != "" {
+		candidates = filterExecutables(executables, bin)
+	} else if named := filterExecutables(executables, tool); len(named) > 0 {
+		candidates = named
+	}
+
+	switch len(candidates) {
+	case 1:
+		return filepath.Join(toolPath, candidates[0]), nil
+	case 0:
+		if bin != "" {
+			return "", fmt.Errorf("no executable named %s found in %s", bin, toolPath)
+		}
+		return "", fmt.Errorf("no executable found in %s", toolPath)
+	default:
+		return "", fmt.Errorf("found %d executables in %s: %s", len(candidates), toolPath, strings.Join(candidates, ", "))
+	}
+}
+
+// listExecutables returns the relative paths of the executables under a tool path, sorted by path.
+// A file is executable if it has an .exe extension or any executable permission bit set.
+// Symlinks to executables are included.
+func listExecutables(toolPath string) ([]string, error) {
+	executables := []string{}
+
+	err := filepath.WalkDir(toolPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		fi, err := os.Stat(p)
+		if err != nil || !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		if !strings.EqualFold(filepath.Ext(p), windowsExecutableExt) && fi.Mode().Perm()&0o111 == 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(toolPath, p)
+		if err != nil {
+			return err
+		}
+
+		executables = append(executables, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(executables)
+	return executables, nil
+}
+
+// filterExecutables returns the executables named name or name.exe.
+func filterExecutables(executables []string, name string) []string {
+	filtered := []string{}
+	for _, e := range executables {
+		base := filepath.Base(e)
+		if base == name || strings.EqualFold(base, name+windowsExecutableExt) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
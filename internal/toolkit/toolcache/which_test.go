@@ -0,0 +1,117 @@
+package toolcache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestFindExecutable(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]os.FileMode
+		versionSpec string
+		bin         string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "errors_if_the_tool_is_not_cached",
+			versionSpec: "^2.0.0",
+			files:       map[string]os.FileMode{"tool": 0o755},
+			wantErr:     true,
+		},
+		{
+			name:        "errors_if_there_are_no_executables",
+			versionSpec: "*",
+			files:       map[string]os.FileMode{"README": 0o644},
+			wantErr:     true,
+		},
+		{
+			name:        "returns_the_only_executable",
+			versionSpec: "*",
+			files:       map[string]os.FileMode{"bin/my-tool": 0o755, "README": 0o644},
+			want:        filepath.Join("bin", "my-tool"),
+		},
+		{
+			name:        "prefers_the_executable_named_after_the_tool",
+			versionSpec: "*",
+			files:       map[string]os.FileMode{"bin/tool": 0o755, "bin/helper": 0o755},
+			want:        filepath.Join("bin", "tool"),
+		},
+		{
+			name:        "errors_if_there_are_several_candidates",
+			versionSpec: "*",
+			files:       map[string]os.FileMode{"bin/a": 0o755, "bin/b": 0o755},
+			wantErr:     true,
+		},
+		{
+			name:        "returns_the_named_executable",
+			versionSpec: "*",
+			bin:         "helper",
+			files:       map[string]os.FileMode{"bin/tool": 0o755, "bin/helper": 0o755},
+			want:        filepath.Join("bin", "helper"),
+		},
+		{
+			name:        "errors_if_the_named_executable_is_not_found",
+			versionSpec: "*",
+			bin:         "missing",
+			files:       map[string]os.FileMode{"bin/tool": 0o755},
+			wantErr:     true,
+		},
+		{
+			name:        "honors_exe_extension",
+			versionSpec: "*",
+			bin:         "tool",
+			files:       map[string]os.FileMode{"tool.exe": 0o644, "tool.dll": 0o644},
+			want:        "tool.exe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			tc := t.TempDir()
+			t.Setenv(runnerToolCacheLookup, tc)
+
+			p := filepath.Join(tc, "tool", "1.0.0", "x64")
+			for name, mode := range tt.files {
+				mustCreateTestDir(t, filepath.Dir(filepath.Join(p, name)))
+				mustCreateTestFile(t, filepath.Join(p, name), "content")
+				is.NoErr(os.Chmod(filepath.Join(p, name), mode)) // should set mode
+			}
+			mustCreateTestFile(t, getMarkerPath(p), "")
+
+			got, err := FindExecutable("tool", "amd64", tt.versionSpec, tt.bin, FindOptions{})
+
+			if tt.wantErr {
+				is.True(err != nil) // should error
+				return
+			}
+
+			is.NoErr(err)                            // should not error
+			is.Equal(got, filepath.Join(p, tt.want)) // should return the executable path
+		})
+	}
+
+	t.Run("includes_symlinks_to_executables", func(t *testing.T) {
+		is := is.New(t)
+		tc := t.TempDir()
+		t.Setenv(runnerToolCacheLookup, tc)
+
+		p := filepath.Join(tc, "tool", "1.0.0", "x64")
+		mustCreateTestDir(t, filepath.Join(p, "bin"))
+		mustCreateTestDir(t, filepath.Join(p, "lib"))
+		mustCreateTestFile(t, filepath.Join(p, "lib", "cli.js"), "script")
+		is.NoErr(os.Chmod(filepath.Join(p, "lib", "cli.js"), 0o755))                               // should set mode
+		is.NoErr(os.Symlink(filepath.Join("..", "lib", "cli.js"), filepath.Join(p, "bin", "npm"))) // should create symlink
+		mustCreateTestFile(t, getMarkerPath(p), "")
+
+		got, err := FindExecutable("tool", "amd64", "*", "npm", FindOptions{})
+
+		is.NoErr(err)                                 // should not error
+		is.Equal(got, filepath.Join(p, "bin", "npm")) // should return the symlink path
+	})
+}